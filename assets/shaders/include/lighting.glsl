@@ -0,0 +1,26 @@
+#ifndef LIGHTING_GLSL
+#define LIGHTING_GLSL
+
+#include "camera.glsl"
+
+layout (std140) uniform Lighting {
+	vec3 lightPos;
+	vec3 lightColor;
+	vec3 ambientLight;
+};
+
+// ambient + diffuse + specular light reaching fragPos
+vec3 phong(vec3 normal, vec3 fragPos) {
+	vec3 lightDir = normalize(lightPos-fragPos);
+	float diff = max(dot(normal,lightDir), 0.0);
+	vec3 diffuse = diff*lightColor;
+
+	vec3 viewDir = normalize(viewPos-fragPos);
+	vec3 reflectDir = reflect(-lightDir,normal);
+	float spec = pow(max(dot(viewDir,reflectDir),0.0), 32);
+	vec3 specular = 0.5 * spec * lightColor;
+
+	return ambientLight+diffuse+specular;
+}
+
+#endif
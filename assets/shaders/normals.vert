@@ -0,0 +1,15 @@
+#version 330 core
+in vec3 aPos;
+in vec3 aNormal;
+
+out VS_OUT {
+	vec3 normal;
+} vs_out;
+
+uniform mat4 model;
+
+// positions stay in world space, the geometry shader projects them
+void main() {
+	gl_Position = model*vec4(aPos,1.0);
+	vs_out.normal = normalize(mat3(transpose(inverse(model)))*aNormal);
+}
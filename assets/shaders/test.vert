@@ -1,7 +1,9 @@
 #version 330 core
-layout (location = 0) in vec3 aPos;
-layout (location = 1) in vec2 aTexCoord;
-layout (location = 2) in vec3 aNormal;
+in vec3 aPos;
+in vec2 aTexCoord;
+in vec3 aNormal;
+in vec4 aTangent;
+in vec3 aBitangent;
 
 out vec3 ModelPos;
 
@@ -9,17 +11,18 @@ out vec2 TexCoord;
 
 out vec3 Normal;
 out vec3 FragPos;
+out mat3 TBN;
 
 uniform mat4 model;
-uniform mat4 view;
-uniform mat4 proj;
+#include "include/camera.glsl"
 
 void main() {
 	FragPos = vec3(model*vec4(aPos,1.0));
 
-	gl_Position = proj*view*model*vec4(FragPos,1.0f);
+	gl_Position = proj*view*vec4(FragPos,1.0f);
 	TexCoord = vec2(aTexCoord.x, 1.0f - aTexCoord.y);
 	ModelPos = vec3(model[3]);
 
 	Normal = aNormal;
+	TBN = mat3(aTangent.xyz, aBitangent, aNormal);
 }
@@ -0,0 +1,45 @@
+#version 330 core
+
+out vec4 FragColor;
+
+in vec2 TexCoord;
+in vec3 Normal;
+in vec3 FragPos;
+in mat3 TBN;
+in vec4 Tint;
+
+uniform sampler2D texture1;
+#ifdef NORMAL_MAP
+uniform sampler2D normalMap;
+#endif
+#ifdef AO_MAP
+uniform sampler2D aoMap;
+#endif
+#ifdef FOG
+uniform vec3 fogColor;
+uniform float fogDensity;
+#endif
+
+#include "include/lighting.glsl"
+
+
+void main() {
+#ifdef NORMAL_MAP
+	vec3 normal = texture(normalMap,TexCoord).rgb*2.0-1.0;
+	normal = normalize(TBN*normal);
+#else
+	vec3 normal = normalize(Normal);
+#endif
+
+	vec3 light = phong(normal,FragPos);
+#ifdef AO_MAP
+	light *= texture(aoMap,TexCoord).r;
+#endif
+
+	FragColor = vec4(light,1.0) * texture(texture1,TexCoord) * Tint;
+
+#ifdef FOG
+	float fog = exp(-pow(fogDensity*length(viewPos-FragPos), 2.0));
+	FragColor.rgb = mix(fogColor, FragColor.rgb, clamp(fog,0.0,1.0));
+#endif
+}
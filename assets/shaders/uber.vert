@@ -0,0 +1,41 @@
+#version 330 core
+in vec3 aPos;
+in vec2 aTexCoord;
+in vec3 aNormal;
+in vec4 aTangent;
+in vec3 aBitangent;
+
+#ifdef INSTANCED
+in mat4 aInstanceModel;
+in vec4 aInstanceColor;
+#else
+uniform mat4 model;
+#endif
+
+out vec2 TexCoord;
+
+out vec3 Normal;
+out vec3 FragPos;
+out mat3 TBN;
+
+out vec4 Tint;
+
+#include "include/camera.glsl"
+
+void main() {
+#ifdef INSTANCED
+	mat4 modelMat = aInstanceModel;
+	Tint = aInstanceColor;
+#else
+	mat4 modelMat = model;
+	Tint = vec4(1.0);
+#endif
+	FragPos = vec3(modelMat*vec4(aPos,1.0));
+
+	gl_Position = proj*view*vec4(FragPos,1.0f);
+	TexCoord = vec2(aTexCoord.x, 1.0f - aTexCoord.y);
+
+	mat3 normalMat = mat3(transpose(inverse(modelMat)));
+	Normal = normalMat*aNormal;
+	TBN = mat3(mat3(modelMat)*aTangent.xyz, mat3(modelMat)*aBitangent, Normal);
+}
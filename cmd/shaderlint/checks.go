@@ -0,0 +1,336 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/moltenwolfcub/OpenGLGoLearning/helpers/glsl"
+)
+
+// the stages a program can have in the order they run in, like stageInfos in helpers/shaderStages.go
+var stageKinds = []struct {
+	extension string
+	name      string
+}{
+	{".vert", "vertex"},
+	{".tesc", "tessellation control"},
+	{".tese", "tessellation evaluation"},
+	{".geom", "geometry"},
+	{".frag", "fragment"},
+	{".comp", "compute"},
+}
+
+func stageOrder(path string) int {
+	for i, k := range stageKinds {
+		if k.extension == filepath.Ext(path) {
+			return i
+		}
+	}
+	return -1
+}
+
+type stage struct {
+	kind   string
+	path   string
+	source glsl.Source //with its includes, the same for every variant
+	decls  declarations
+}
+
+// a stage's inputs are one element per vertex of the primitive
+func arrayedInputs(kind string) bool {
+	return kind == "tessellation control" || kind == "tessellation evaluation" || kind == "geometry"
+}
+
+func arrayedOutputs(kind string) bool {
+	return kind == "tessellation control"
+}
+
+type glslShape struct {
+	components int
+	columns    int //each column takes a location
+	integer    bool
+}
+
+var glslShapes = map[string]glslShape{
+	"float": {1, 1, false},
+	"vec2":  {2, 1, false},
+	"vec3":  {3, 1, false},
+	"vec4":  {4, 1, false},
+	"mat2":  {2, 2, false},
+	"mat3":  {3, 3, false},
+	"mat4":  {4, 4, false},
+	"int":   {1, 1, true},
+	"ivec2": {2, 1, true},
+	"ivec3": {3, 1, true},
+	"ivec4": {4, 1, true},
+	"uint":  {1, 1, true},
+	"uvec2": {2, 1, true},
+	"uvec3": {3, 1, true},
+	"uvec4": {4, 1, true},
+}
+
+type linter struct {
+	diagnostics []diagnostic
+}
+
+func (l *linter) errorf(pos position, format string, args ...any) {
+	l.diagnostics = append(l.diagnostics, errorAt(pos, format, args...))
+}
+
+func (l *linter) warnf(pos position, format string, args ...any) {
+	d := errorAt(pos, format, args...)
+	d.severity = "warning"
+	l.diagnostics = append(l.diagnostics, d)
+}
+
+// removes the per vertex array e.g. vec3[] -> vec3, false if there isn't one
+func perVertex(glslType string) (string, bool) {
+	start := strings.Index(glslType, "[")
+	if start < 0 {
+		return glslType, false
+	}
+	end := start + strings.Index(glslType[start:], "]")
+	return glslType[:start] + glslType[end+1:], true
+}
+
+// e.g. flat int id
+func describeMember(v variable) string {
+	s := v.glslType + " " + v.name
+	if v.interpolation != "smooth" {
+		s = v.interpolation + " " + s
+	}
+	return s
+}
+
+// checks every input of next is an output of prev with the same type and interpolation
+func (l *linter) checkInterface(prev, next stage) {
+	prevName, nextName := filepath.Base(prev.path), filepath.Base(next.path)
+
+	outputs := make(map[string]variable)
+	for _, v := range prev.decls.variables {
+		if v.storage == "out" {
+			outputs[v.name] = v
+		}
+	}
+	read := make(map[string]bool)
+
+	for _, in := range next.decls.variables {
+		if in.storage != "in" {
+			continue
+		}
+		inType := in.glslType
+		if arrayedInputs(next.kind) {
+			var ok bool
+			if inType, ok = perVertex(in.glslType); !ok {
+				l.errorf(in.pos, "input %s has to be an array in a %s shader", in.name, next.kind)
+				continue
+			}
+		}
+
+		out, ok := outputs[in.name]
+		if !ok {
+			l.errorf(in.pos, "input %s isn't an output of %s", in.name, prevName)
+			continue
+		}
+		read[in.name] = true
+
+		outType := out.glslType
+		if arrayedOutputs(prev.kind) {
+			outType, _ = perVertex(out.glslType)
+		}
+		switch {
+		case inType != outType:
+			l.errorf(in.pos, "input %s is %s but %s outputs %s", in.name, inType, prevName, outType)
+		case in.interpolation != out.interpolation:
+			l.errorf(in.pos, "input %s is %s but it's %s in %s", in.name, in.interpolation, out.interpolation, prevName)
+		case in.location >= 0 && out.location >= 0 && in.location != out.location:
+			l.errorf(in.pos, "input %s is at location %d but %s outputs it at %d", in.name, in.location, prevName, out.location)
+		}
+	}
+	for _, out := range prev.decls.variables {
+		if out.storage == "out" && !read[out.name] {
+			l.warnf(out.pos, "output %s isn't read by %s", out.name, nextName)
+		}
+	}
+
+	outBlocks := make(map[string]block)
+	for _, b := range prev.decls.blocks {
+		if b.storage == "out" {
+			outBlocks[b.name] = b
+		}
+	}
+	readBlocks := make(map[string]bool)
+
+	for _, in := range next.decls.blocks {
+		if in.storage != "in" {
+			continue
+		}
+		out, ok := outBlocks[in.name]
+		if !ok {
+			l.errorf(in.pos, "input block %s isn't an output of %s", in.name, prevName)
+			continue
+		}
+		readBlocks[in.name] = true
+
+		if arrayedInputs(next.kind) && in.instanceArray == "" {
+			l.errorf(in.pos, "input block %s has to be an array in a %s shader", in.name, next.kind)
+		}
+		for i := 0; i < max(len(in.members), len(out.members)); i++ {
+			inMember, outMember := "nothing", "nothing"
+			if i < len(in.members) {
+				inMember = describeMember(in.members[i])
+			}
+			if i < len(out.members) {
+				outMember = describeMember(out.members[i])
+			}
+			if inMember != outMember {
+				l.errorf(in.pos, "input block %s has %s where %s outputs %s", in.name, inMember, prevName, outMember)
+				break
+			}
+		}
+	}
+	for _, out := range prev.decls.blocks {
+		if out.storage == "out" && !readBlocks[out.name] {
+			l.warnf(out.pos, "output block %s isn't read by %s", out.name, nextName)
+		}
+	}
+}
+
+// checks a uniform has the same type in every file of the program
+// and that uniform blocks with the same name have the same members
+func (l *linter) checkUniforms(stages []stage) {
+	uniforms := make(map[string]variable)
+	check := func(u variable) {
+		first, ok := uniforms[u.name]
+		if !ok {
+			uniforms[u.name] = u
+			return
+		}
+		switch {
+		case first.pos == u.pos:
+			//the same include in more than one stage
+		case first.glslType != u.glslType:
+			l.errorf(u.pos, "uniform %s is %s but it's %s at %s", u.name, u.glslType, first.glslType, first.pos)
+		case first.block != u.block:
+			l.errorf(u.pos, "uniform %s is also declared at %s", u.name, first.pos)
+		}
+	}
+
+	blocks := make(map[string]block)
+	for _, s := range stages {
+		for _, v := range s.decls.variables {
+			if v.storage == "uniform" {
+				check(v)
+			}
+		}
+		for _, b := range s.decls.blocks {
+			if b.storage != "uniform" {
+				continue
+			}
+			if first, ok := blocks[b.name]; ok && first.pos != b.pos {
+				if !sameMembers(first, b) {
+					l.errorf(b.pos, "uniform block %s doesn't match the one at %s", b.name, first.pos)
+				}
+			} else {
+				blocks[b.name] = b
+			}
+			if b.instance == "" {
+				for _, m := range b.members {
+					check(m)
+				}
+			}
+		}
+	}
+}
+
+func sameMembers(a, b block) bool {
+	if len(a.members) != len(b.members) {
+		return false
+	}
+	for i := range a.members {
+		if describeMember(a.members[i]) != describeMember(b.members[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// checks each vertex input is one Object uploads with a type it can take
+// and that inputs with a layout location don't share locations
+// the glsl package has what Object uploads, helpers uses the same tables
+func (l *linter) checkAttributes(vertex stage) {
+	for _, b := range vertex.decls.blocks {
+		if b.storage == "in" {
+			l.errorf(b.pos, "vertex inputs can't be in a block (%s)", b.name)
+		}
+	}
+
+	var known []string
+	for semantic, names := range glsl.SemanticInputs {
+		if _, ok := glsl.MeshAttributes[semantic]; ok {
+			known = append(known, names...)
+		}
+	}
+	for name := range glsl.InstanceInputs {
+		known = append(known, name)
+	}
+	sort.Strings(known)
+
+	used := make(map[int]variable)
+	for _, in := range vertex.decls.variables {
+		if in.storage != "in" {
+			continue
+		}
+
+		semantic := glsl.SemanticOf(in.name)
+		meshType, uploaded := glsl.MeshAttributes[semantic]
+		instanceType, instanced := glsl.InstanceInputs[in.name]
+		switch {
+		case instanced:
+			if in.glslType != instanceType {
+				l.errorf(in.pos, "input %s is %s but instance data gives %s", in.name, in.glslType, instanceType)
+			}
+		case uploaded:
+			shape, ok := glslShapes[in.glslType]
+			given := glslShapes[meshType]
+			if !ok || shape.integer || shape.columns > 1 ||
+				(shape.components != given.components && !(shape.components == 4 && given.components < 4)) {
+				l.errorf(in.pos, "input %s is %s but Object uploads the %s as %s", in.name, in.glslType, semantic, meshType)
+			}
+		case semantic != "":
+			l.errorf(in.pos, "input %s is for the %s but mesh verticies haven't got one", in.name, semantic)
+		default:
+			l.errorf(in.pos, "input %s isn't uploaded by Object, it can be one of %s", in.name, strings.Join(known, ", "))
+		}
+
+		if in.location < 0 {
+			continue
+		}
+		count := locationCount(in.glslType)
+		for loc := in.location; loc < in.location+count; loc++ {
+			if other, ok := used[loc]; ok {
+				l.errorf(in.pos, "input %s overlaps %s at location %d", in.name, other.name, loc)
+				break
+			}
+			used[loc] = in
+		}
+		if in.location+count > 16 {
+			l.warnf(in.pos, "input %s goes up to location %d, GL only has to support 16", in.name, in.location+count-1)
+		}
+	}
+}
+
+// how many attribute locations an input of the type takes
+func locationCount(glslType string) int {
+	base, size, _ := strings.Cut(glslType, "[")
+	count := 1
+	if shape, ok := glslShapes[base]; ok {
+		count = shape.columns
+	}
+	if n, err := strconv.Atoi(strings.TrimSuffix(size, "]")); err == nil {
+		count *= n
+	}
+	return count
+}
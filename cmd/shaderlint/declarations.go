@@ -0,0 +1,339 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/moltenwolfcub/OpenGLGoLearning/helpers/glsl"
+)
+
+/*
+Only the global declarations are parsed: in, out and uniform variables,
+interface blocks and uniform blocks. Function bodies and structs are skipped.
+*/
+
+type token struct {
+	text string
+	pos  position
+}
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+
+func isIdentifier(s string) bool {
+	return identifierPattern.MatchString(s)
+}
+
+// the lines are from the source, with comments already blanked out
+func tokenize(source glsl.Source, lines []glsl.Line) []token {
+	var tokens []token
+	for _, line := range lines {
+		text := line.Text
+		pos := position{source.Files[line.File], line.Number}
+		for i := 0; i < len(text); {
+			c := text[i]
+			start := i
+			switch {
+			case c == ' ' || c == '\t' || c == '\r':
+				i++
+				continue
+			case c == '_' || isLetter(c):
+				for i < len(text) && (text[i] == '_' || isLetter(text[i]) || isDigit(text[i])) {
+					i++
+				}
+			case isDigit(c) || (c == '.' && i+1 < len(text) && isDigit(text[i+1])):
+				for i < len(text) && (text[i] == '.' || isLetter(text[i]) || isDigit(text[i])) {
+					i++
+				}
+			default:
+				i++
+			}
+			tokens = append(tokens, token{text[start:i], pos})
+		}
+	}
+	return tokens
+}
+
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+type variable struct {
+	name          string
+	glslType      string //with any array size e.g. vec3[4]
+	storage       string //in, out or uniform
+	interpolation string //flat, noperspective or smooth
+	location      int    //-1 if it hasn't got a layout location
+	block         string //the block it's a member of
+	pos           position
+}
+
+type block struct {
+	name          string
+	storage       string
+	instance      string //empty if the members are global
+	instanceArray string //e.g. [] for a geometry shader's inputs
+	members       []variable
+	pos           position
+}
+
+type declarations struct {
+	variables []variable //outside of blocks
+	blocks    []block
+}
+
+type declarationParser struct {
+	tokens []token
+	i      int
+	decls  declarations
+}
+
+func parseDeclarations(tokens []token) (declarations, error) {
+	p := declarationParser{tokens: tokens}
+	for p.i < len(p.tokens) {
+		statement, end := p.statement()
+		switch end {
+		case ";":
+			if _, err := p.declaration(statement, "", nil); err != nil {
+				return p.decls, err
+			}
+		case "{":
+			if err := p.braces(statement); err != nil {
+				return p.decls, err
+			}
+		case "}":
+			return p.decls, errorAt(p.tokens[p.i-1].pos, "unexpected }")
+		default:
+			return p.decls, errorAt(statement[0].pos, "unexpected end of file after %s", statement[len(statement)-1].text)
+		}
+	}
+	return p.decls, nil
+}
+
+// the tokens up to the next ; { or } outside of brackets, and which of them it was
+func (p *declarationParser) statement() ([]token, string) {
+	start := p.i
+	depth := 0
+	for ; p.i < len(p.tokens); p.i++ {
+		switch t := p.tokens[p.i].text; t {
+		case "(", "[":
+			depth++
+		case ")", "]":
+			depth--
+		case ";", "{", "}":
+			if depth == 0 {
+				p.i++
+				return p.tokens[start : p.i-1], t
+			}
+		}
+	}
+	return p.tokens[start:], ""
+}
+
+// skips to the } matching the { just read
+func (p *declarationParser) skipBraces() {
+	for depth := 1; p.i < len(p.tokens) && depth > 0; p.i++ {
+		switch p.tokens[p.i].text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+		}
+	}
+}
+
+// a function, struct or block, the { has just been read
+func (p *declarationParser) braces(statement []token) error {
+	q, rest, err := parseQualifiers(statement)
+	if err != nil {
+		return err
+	}
+	if hasToken(rest, "(") {
+		p.skipBraces() //function
+		return nil
+	}
+	if hasToken(rest, "struct") {
+		p.skipBraces()
+		p.statement() //any variables declared with it
+		return nil
+	}
+	if len(rest) != 1 || !isIdentifier(rest[0].text) {
+		return errorAt(statement[0].pos, "can't parse the block declaration")
+	}
+	b := block{name: rest[0].text, storage: q.storage, pos: rest[0].pos}
+
+	for {
+		member, end := p.statement()
+		if end == "}" && len(member) == 0 {
+			break
+		}
+		if end != ";" {
+			return errorAt(b.pos, "can't parse the members of block %s", b.name)
+		}
+		vars, err := p.declaration(member, b.storage, &q)
+		if err != nil {
+			return err
+		}
+		for _, v := range vars {
+			v.block = b.name
+			b.members = append(b.members, v)
+		}
+	}
+
+	instance, end := p.statement()
+	if end != ";" {
+		return errorAt(b.pos, "block %s needs a ; after it", b.name)
+	}
+	if len(instance) > 0 {
+		b.instance = instance[0].text
+		j := 1
+		b.instanceArray = arraySuffix(instance, &j)
+	}
+	if !strings.HasPrefix(b.name, "gl_") {
+		p.decls.blocks = append(p.decls.blocks, b)
+	}
+	return nil
+}
+
+type qualifiers struct {
+	storage       string
+	interpolation string
+	location      int
+}
+
+var storageQualifiers = []string{"in", "out", "uniform", "buffer", "attribute", "varying", "const", "shared"}
+var interpolationQualifiers = []string{"flat", "noperspective", "smooth"}
+var otherQualifiers = []string{"centroid", "sample", "patch", "invariant", "precise", "highp", "mediump", "lowp",
+	"readonly", "writeonly", "coherent", "volatile", "restrict"}
+
+// reads any layout(...) and qualifiers from the start of a declaration
+func parseQualifiers(tokens []token) (qualifiers, []token, error) {
+	q := qualifiers{interpolation: "smooth", location: -1}
+	i := 0
+	for i < len(tokens) {
+		t := tokens[i].text
+		switch {
+		case t == "layout":
+			end := i + 1
+			for end < len(tokens) && tokens[end].text != ")" {
+				end++
+			}
+			if i+1 >= len(tokens) || tokens[i+1].text != "(" || end == len(tokens) {
+				return q, nil, errorAt(tokens[i].pos, "can't parse the layout")
+			}
+			for j := i + 2; j+2 < end; j++ {
+				if tokens[j].text == "location" && tokens[j+1].text == "=" {
+					if l, err := strconv.Atoi(tokens[j+2].text); err == nil {
+						q.location = l
+					}
+				}
+			}
+			i = end + 1
+		case contains(storageQualifiers, t):
+			q.storage = t
+			i++
+		case contains(interpolationQualifiers, t):
+			q.interpolation = t
+			i++
+		case contains(otherQualifiers, t):
+			i++
+		default:
+			return q, tokens[i:], nil
+		}
+	}
+	return q, nil, nil
+}
+
+// adds the in, out and uniform variables in a declaration
+// members of a block get the block's storage and interpolation unless they have their own
+func (p *declarationParser) declaration(tokens []token, storage string, outer *qualifiers) ([]variable, error) {
+	q, rest, err := parseQualifiers(tokens)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) == 0 || rest[0].text == "precision" || (len(rest) > 2 && rest[2].text == "(") {
+		return nil, nil //e.g. layout (triangles) in; or a function prototype
+	}
+	if q.storage == "" {
+		q.storage = storage
+	}
+	if outer != nil && q.interpolation == "smooth" {
+		q.interpolation = outer.interpolation
+	}
+	if q.storage != "in" && q.storage != "out" && q.storage != "uniform" {
+		return nil, nil
+	}
+
+	if !isIdentifier(rest[0].text) {
+		return nil, errorAt(rest[0].pos, "can't parse the declaration")
+	}
+	j := 1
+	glslType := rest[0].text + arraySuffix(rest, &j)
+
+	var vars []variable
+	for j < len(rest) {
+		name := rest[j]
+		if !isIdentifier(name.text) {
+			return nil, errorAt(name.pos, "can't parse the declaration of a %s", glslType)
+		}
+		j++
+		v := variable{
+			name:          name.text,
+			glslType:      glslType + arraySuffix(rest, &j),
+			storage:       q.storage,
+			interpolation: q.interpolation,
+			location:      q.location,
+			pos:           name.pos,
+		}
+		if !strings.HasPrefix(v.name, "gl_") {
+			vars = append(vars, v)
+		}
+
+		//skips any initialiser to the next name
+		for depth := 0; j < len(rest) && !(depth == 0 && rest[j].text == ","); j++ {
+			switch rest[j].text {
+			case "(", "[":
+				depth++
+			case ")", "]":
+				depth--
+			}
+		}
+		j++
+	}
+
+	if outer == nil {
+		p.decls.variables = append(p.decls.variables, vars...)
+	}
+	return vars, nil
+}
+
+// reads any [...] at tokens[*j] e.g. [4] or []
+func arraySuffix(tokens []token, j *int) string {
+	suffix := ""
+	for *j < len(tokens) && tokens[*j].text == "[" {
+		suffix += "["
+		for *j++; *j < len(tokens) && tokens[*j].text != "]"; *j++ {
+			suffix += tokens[*j].text
+		}
+		suffix += "]"
+		*j++
+	}
+	return suffix
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasToken(tokens []token, text string) bool {
+	for _, t := range tokens {
+		if t.text == text {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLintProgram(t *testing.T) {
+	tests := []struct {
+		name  string
+		files string
+		want  []string
+	}{
+		{
+			//the mismatch that only showed up when the program linked
+			name:  "test.vert with blue.frag",
+			files: "test.vert,blue.frag",
+			want: []string{
+				"testdata/test.vert:10: warning: output TexCoord isn't read by blue.frag",
+				"testdata/test.vert:12: warning: output Normal isn't read by blue.frag",
+				"testdata/test.vert:13: warning: output FragPos isn't read by blue.frag",
+				"testdata/test.vert:14: warning: output TBN isn't read by blue.frag",
+			},
+		},
+		{
+			name:  "test.vert with quadTexture.frag",
+			files: "test.vert,quadTexture.frag",
+			want: []string{
+				"testdata/test.vert:8: warning: output ModelPos isn't read by quadTexture.frag",
+			},
+		},
+		{
+			name:  "interface and uniform mismatches",
+			files: "test.vert,mismatch.frag",
+			want: []string{
+				"testdata/mismatch.frag:4: error: input ModelPos is vec4 but test.vert outputs vec3",
+				"testdata/mismatch.frag:5: error: input TexCoord is flat but it's smooth in test.vert",
+				"testdata/mismatch.frag:6: error: input Tint isn't an output of test.vert",
+				"testdata/test.vert:12: warning: output Normal isn't read by mismatch.frag",
+				"testdata/test.vert:13: warning: output FragPos isn't read by mismatch.frag",
+				"testdata/test.vert:14: warning: output TBN isn't read by mismatch.frag",
+				"testdata/mismatch.frag:8: error: uniform model is vec3 but it's mat4 at testdata/test.vert:16",
+				"testdata/mismatch.frag:9: error: uniform block Camera doesn't match the one at testdata/include/camera.glsl:4",
+			},
+		},
+		{
+			name:  "vertex inputs",
+			files: "inputs.vert,inputs.frag",
+			want: []string{
+				"testdata/inputs.vert:3: error: input aUV overlaps aPos at location 0",
+				"testdata/inputs.vert:4: error: input aNormal is ivec3 but Object uploads the normal as vec3",
+				"testdata/inputs.vert:5: error: input aColor is for the color but mesh verticies haven't got one",
+				"testdata/inputs.vert:6: error: input aInstanceModel is vec3 but instance data gives mat4",
+				"testdata/inputs.vert:7: error: input aWeight isn't uploaded by Object, it can be one of " +
+					"aBitangent, aInstanceColor, aInstanceModel, aInstanceTexture, aNormal, aPos, aPosition, aTangent, aTexCoord, aUV",
+				"testdata/inputs.vert:8: error: input aInstanceModelToo isn't uploaded by Object, it can be one of " +
+					"aBitangent, aInstanceColor, aInstanceModel, aInstanceTexture, aNormal, aPos, aPosition, aTangent, aTexCoord, aUV",
+				"testdata/inputs.vert:8: warning: input aInstanceModelToo goes up to location 18, GL only has to support 16",
+			},
+		},
+		{
+			name:  "only some variants",
+			files: "fog.vert,fog.frag",
+			want: []string{
+				"testdata/fog.frag:4: error: input FogAmount isn't an output of fog.vert (in 2 of 4 variants e.g. [])",
+			},
+		},
+		{
+			name:  "geometry shader",
+			files: "normals.vert,normals.geom,normals.frag",
+			want: []string{
+				"testdata/normals.geom:9: error: input Colour has to be an array in a geometry shader",
+				"testdata/normals.geom:5: error: input block VS_OUT has flat vec3 normal where normals.vert outputs vec3 normal",
+			},
+		},
+		{
+			name:  "include cycle",
+			files: "cycle.vert,inputs.frag",
+			want: []string{
+				"testdata/include/cycle.glsl:1: error: include cycle: testdata/include/cycle.glsl -> testdata/include/cycle.glsl",
+			},
+		},
+		{
+			name:  "parse error and #error",
+			files: "broken.vert,inputs.frag",
+			want: []string{
+				"testdata/broken.vert:11: error: unexpected } (in 1 of 2 variants e.g. [])",
+				"testdata/broken.vert:5: error: #error shadows aren't done yet (in 1 of 2 variants e.g. [SHADOWS])",
+			},
+		},
+		{
+			name:  "missing file",
+			files: "missing.vert,inputs.frag",
+			want:  []string{"testdata/missing.vert: error: open testdata/missing.vert: no such file or directory"},
+		},
+		{
+			name:  "two vertex shaders",
+			files: "inputs.vert,normals.vert",
+			want:  []string{"testdata/normals.vert: error: the program already has a vertex shader (testdata/inputs.vert)"},
+		},
+		{
+			name:  "no vertex shader",
+			files: "blue.frag",
+			want:  []string{"testdata/blue.frag: error: the program has no vertex shader"},
+		},
+		{
+			name:  "unknown stage",
+			files: "inputs.vert,notes.txt",
+			want:  []string{`testdata/notes.txt: error: can't tell the shader stage from ".txt"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var files []string
+			for _, f := range strings.Split(tt.files, ",") {
+				files = append(files, filepath.Join("testdata", f))
+			}
+			var got []string
+			for _, d := range lintProgram(files) {
+				got = append(got, d.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestFindPrograms(t *testing.T) {
+	programs, err := findPrograms("testdata/find")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"testdata/find/a.vert", "testdata/find/a.frag"}}
+	if !reflect.DeepEqual(programs, want) {
+		t.Errorf("got %q, want %q", programs, want)
+	}
+}
+
+func TestFeatureSets(t *testing.T) {
+	if got, want := featureSets([]string{"A", "B"}), [][]string{nil, {"A"}, {"B"}, {"A", "B"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	many := []string{"A", "B", "C", "D", "E", "F", "G", "H", "I"}
+	sets := featureSets(many)
+	if len(sets) != len(many)+2 || sets[0] != nil || !reflect.DeepEqual(sets[len(sets)-1], many) {
+		t.Errorf("more than %d features gave %q, want none, each on its own and all of them", maxFeatures, sets)
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/moltenwolfcub/OpenGLGoLearning/helpers/glsl"
+)
+
+/*
+Checks the shaders in assets/shaders for mistakes that would otherwise only
+show up when a program links on a real GPU, no GL context is needed.
+
+	go run ./cmd/shaderlint
+	go run ./cmd/shaderlint test.vert,blue.frag
+
+each argument is a program as a comma separated list of its files, without
+any, every group of files with the same name e.g. normals.vert, normals.geom
+and normals.frag is checked as a program. Features a shader tests with
+#ifdef are checked in every combination like ShaderVariants can build them.
+
+For each program it checks that
+  - each stage's inputs are outputs of the stage before with the same type
+  - uniforms and uniform blocks are the same in every file
+  - the vertex inputs are attributes Object uploads
+*/
+
+// more features than this are only checked on their own and all together
+const maxFeatures = 8
+
+type position struct {
+	file string
+	line int //0 for the whole file
+}
+
+func (p position) String() string {
+	if p.line == 0 {
+		return p.file
+	}
+	return fmt.Sprintf("%s:%d", p.file, p.line)
+}
+
+type diagnostic struct {
+	pos      position
+	severity string
+	message  string
+}
+
+func (d diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s: %s", d.pos, d.severity, d.message)
+}
+
+func errorAt(pos position, format string, args ...any) diagnostic {
+	return diagnostic{pos, "error", fmt.Sprintf(format, args...)}
+}
+
+// a diagnostic at the line a preprocessor or parse error is from
+func sourceError(path string, err error) diagnostic {
+	var d diagnostic
+	var e *glsl.Error
+	switch {
+	case errors.As(err, &d):
+		return d
+	case errors.As(err, &e):
+		return errorAt(position{e.File, e.Line}, "%s", e.Message)
+	}
+	return errorAt(position{file: path}, "%v", err)
+}
+
+func main() {
+	dir := flag.String("dir", "assets/shaders", "the directory programs are found in")
+	flag.Parse()
+
+	var programs [][]string
+	if flag.NArg() == 0 {
+		var err error
+		if programs, err = findPrograms(*dir); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+	for _, arg := range flag.Args() {
+		var files []string
+		for _, f := range strings.Split(arg, ",") {
+			if _, err := os.Stat(f); err != nil {
+				f = filepath.Join(*dir, f)
+			}
+			files = append(files, f)
+		}
+		programs = append(programs, files)
+	}
+
+	errorCount, warningCount := 0, 0
+	for _, files := range programs {
+		for _, d := range lintProgram(files) {
+			fmt.Println(d.Error())
+			if d.severity == "error" {
+				errorCount++
+			} else {
+				warningCount++
+			}
+		}
+	}
+	fmt.Printf("%d programs: %d errors, %d warnings\n", len(programs), errorCount, warningCount)
+	if errorCount > 0 {
+		os.Exit(1)
+	}
+}
+
+// groups the shader files in dir by name
+// a group without a vertex or compute shader is skipped with a note
+func findPrograms(dir string) ([][]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string][]string)
+	var names []string
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if e.IsDir() || stageOrder(path) < 0 {
+			continue
+		}
+		name := strings.TrimSuffix(e.Name(), filepath.Ext(path))
+		if _, ok := groups[name]; !ok {
+			names = append(names, name)
+		}
+		groups[name] = append(groups[name], path)
+	}
+	sort.Strings(names)
+
+	var programs [][]string
+	for _, name := range names {
+		files := groups[name]
+		sort.Slice(files, func(i, j int) bool { return stageOrder(files[i]) < stageOrder(files[j]) })
+		if first := stageKinds[stageOrder(files[0])].name; first != "vertex" && first != "compute" {
+			fmt.Printf("%s: skipped, there's no %s.vert to go with it (pass the program e.g. other.vert,%s)\n",
+				strings.Join(files, ", "), name, filepath.Base(files[0]))
+			continue
+		}
+		programs = append(programs, files)
+	}
+	return programs, nil
+}
+
+// checks every variant of the program, a problem only some variants have
+// says which they are
+func lintProgram(files []string) []diagnostic {
+	var stages []stage
+	kinds := make(map[string]string)
+	for _, f := range files {
+		order := stageOrder(f)
+		if order < 0 {
+			return []diagnostic{errorAt(position{file: f}, "can't tell the shader stage from %q", filepath.Ext(f))}
+		}
+		kind := stageKinds[order].name
+		if other, ok := kinds[kind]; ok {
+			return []diagnostic{errorAt(position{file: f}, "the program already has a %s shader (%s)", kind, other)}
+		}
+		kinds[kind] = f
+		stages = append(stages, stage{kind: kind, path: f})
+	}
+	sort.SliceStable(stages, func(i, j int) bool { return stageOrder(stages[i].path) < stageOrder(stages[j].path) })
+
+	//the includes are the same in every variant so each file is only read once
+	tested := make(map[string]bool)
+	var features []string
+	for i := range stages {
+		source, err := glsl.ReadSource(stages[i].path)
+		if err != nil {
+			return []diagnostic{sourceError(stages[i].path, err)}
+		}
+		stages[i].source = source
+		for _, f := range source.Features() {
+			if !tested[f] {
+				tested[f] = true
+				features = append(features, f)
+			}
+		}
+	}
+	sort.Strings(features)
+	variants := featureSets(features)
+
+	type found struct {
+		diagnostic
+		variants []string
+	}
+	var order []string
+	byKey := make(map[string]*found)
+	for _, features := range variants {
+		defines := make(map[string]string)
+		for _, f := range features {
+			defines[f] = ""
+		}
+		name := "[" + strings.Join(features, ",") + "]"
+
+		for _, d := range lintVariant(stages, defines) {
+			key := d.Error()
+			if _, ok := byKey[key]; !ok {
+				byKey[key] = &found{diagnostic: d}
+				order = append(order, key)
+			}
+			byKey[key].variants = append(byKey[key].variants, name)
+		}
+	}
+
+	diagnostics := make([]diagnostic, len(order))
+	for i, key := range order {
+		f := byKey[key]
+		if len(f.variants) < len(variants) {
+			f.message += fmt.Sprintf(" (in %d of %d variants e.g. %s)", len(f.variants), len(variants), f.variants[0])
+		}
+		diagnostics[i] = f.diagnostic
+	}
+	return diagnostics
+}
+
+func lintVariant(stages []stage, defines map[string]string) []diagnostic {
+	var l linter
+	parsed := make([]stage, len(stages))
+	for i, s := range stages {
+		lines, err := s.source.Active(defines)
+		if err == nil {
+			s.decls, err = parseDeclarations(tokenize(s.source, lines))
+		}
+		if err != nil {
+			return append(l.diagnostics, sourceError(s.path, err))
+		}
+		parsed[i] = s
+	}
+
+	if parsed[0].kind == "compute" {
+		return l.diagnostics
+	}
+	if parsed[0].kind != "vertex" {
+		l.errorf(position{file: parsed[0].path}, "the program has no vertex shader")
+		return l.diagnostics
+	}
+	for i := 1; i < len(parsed); i++ {
+		l.checkInterface(parsed[i-1], parsed[i])
+	}
+	l.checkUniforms(parsed)
+	l.checkAttributes(parsed[0])
+	return l.diagnostics
+}
+
+// every combination of the features, or if there are too many
+// none of them, each on its own and all of them
+func featureSets(features []string) [][]string {
+	if len(features) > maxFeatures {
+		sets := [][]string{nil}
+		for _, f := range features {
+			sets = append(sets, []string{f})
+		}
+		return append(sets, features)
+	}
+
+	sets := make([][]string, 0, 1<<len(features))
+	for mask := 0; mask < 1<<len(features); mask++ {
+		var set []string
+		for i, f := range features {
+			if mask&(1<<i) != 0 {
+				set = append(set, f)
+			}
+		}
+		sets = append(sets, set)
+	}
+	return sets
+}
@@ -0,0 +1,11 @@
+#version 330 core
+in vec3 aPos;
+
+#ifdef SHADOWS
+#error shadows aren't done yet
+#endif
+
+void main() {
+	gl_Position = vec4(aPos, 1.0);
+}
+}
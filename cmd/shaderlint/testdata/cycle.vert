@@ -0,0 +1,5 @@
+#version 330 core
+#include "include/cycle.glsl"
+in vec3 aPos;
+
+void main() {}
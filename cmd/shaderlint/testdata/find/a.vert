@@ -0,0 +1,3 @@
+#version 330 core
+in vec3 aPos;
+void main() {}
@@ -0,0 +1,12 @@
+#version 330 core
+out vec4 FragColor;
+
+in float FogAmount;
+
+void main() {
+#if defined(FOG) && !defined(NO_TINT)
+	FragColor = vec4(FogAmount);
+#else
+	FragColor = vec4(1.0);
+#endif
+}
@@ -0,0 +1,13 @@
+#version 330 core
+in vec3 aPos;
+
+#ifdef FOG
+out float FogAmount;
+#endif
+
+void main() {
+	gl_Position = vec4(aPos, 1.0);
+#ifdef FOG
+	FogAmount = gl_Position.z;
+#endif
+}
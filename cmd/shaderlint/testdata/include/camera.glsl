@@ -0,0 +1,10 @@
+#ifndef CAMERA_GLSL
+#define CAMERA_GLSL
+
+layout (std140) uniform Camera {
+	mat4 proj;
+	mat4 view;
+	vec3 viewPos;
+};
+
+#endif
@@ -0,0 +1,13 @@
+#version 330 core
+layout (location = 0) in vec3 aPos;
+layout (location = 0) in vec2 aUV;
+in ivec3 aNormal;
+in vec4 aColor;
+in vec3 aInstanceModel;
+in float aWeight;
+layout (location = 15) in mat4 aInstanceModelToo;
+/* in vec3 aCommented; */
+
+void main() {
+	gl_Position = vec4(aPos, 1.0);
+}
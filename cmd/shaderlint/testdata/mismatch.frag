@@ -0,0 +1,16 @@
+#version 330 core
+out vec4 FragColor;
+
+in vec4 ModelPos;
+flat in vec2 TexCoord;
+in vec3 Tint;
+
+uniform vec3 model;
+layout (std140) uniform Camera {
+	mat4 proj;
+	vec3 viewPos;
+};
+
+void main() {
+	FragColor = vec4(ModelPos.xyz*Tint*model, TexCoord.x);
+}
@@ -0,0 +1,12 @@
+#version 330 core
+in vec3 aPos;
+in vec3 aNormal;
+
+out VS_OUT {
+	vec3 normal;
+} vs_out;
+
+void main() {
+	gl_Position = vec4(aPos, 1.0);
+	vs_out.normal = aNormal;
+}
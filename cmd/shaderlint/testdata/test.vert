@@ -0,0 +1,28 @@
+#version 330 core
+in vec3 aPos;
+in vec2 aTexCoord;
+in vec3 aNormal;
+in vec4 aTangent;
+in vec3 aBitangent;
+
+out vec3 ModelPos;
+
+out vec2 TexCoord;
+
+out vec3 Normal;
+out vec3 FragPos;
+out mat3 TBN;
+
+uniform mat4 model;
+#include "include/camera.glsl"
+
+void main() {
+	FragPos = vec3(model*vec4(aPos,1.0));
+
+	gl_Position = proj*view*vec4(FragPos,1.0f);
+	TexCoord = vec2(aTexCoord.x, 1.0f - aTexCoord.y);
+	ModelPos = vec3(model[3]);
+
+	Normal = aNormal;
+	TBN = mat3(aTangent.xyz, aBitangent, aNormal);
+}
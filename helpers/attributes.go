@@ -0,0 +1,298 @@
+package helpers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/moltenwolfcub/OpenGLGoLearning/helpers/glsl"
+)
+
+/*
+Vertex attributes are matched to a program's inputs by name
+instead of by the order their buffers were built in.
+
+A vertex struct field tagged with a semantic e.g. `attr:"normal"` is
+bound to whichever of that semantic's input names the shader declares,
+any other name e.g. `attr:"aBoneIds,integer"` has to match the input exactly.
+Each Object keeps one VAO per program it has been drawn with.
+*/
+
+// the semantic a shader input is for, or the input's own name if it hasn't got one
+// the names each semantic is bound to are in glsl.SemanticInputs
+func semanticOf(input string) string {
+	if semantic := glsl.SemanticOf(input); semantic != "" {
+		return semantic
+	}
+	return input
+}
+
+// an active vertex input of a linked program
+type shaderInput struct {
+	name     string
+	location uint32
+	glslType uint32
+}
+
+func activeInputs(program ProgramID) []shaderInput {
+	count := backend.GetProgramiv(uint32(program), gl.ACTIVE_ATTRIBUTES)
+
+	inputs := make([]shaderInput, 0, count)
+	for i := int32(0); i < count; i++ {
+		name, _, glslType := backend.GetActiveAttrib(uint32(program), uint32(i))
+
+		location := backend.GetAttribLocation(uint32(program), name)
+		if location < 0 {
+			continue //built in inputs like gl_VertexID have no location
+		}
+		inputs = append(inputs, shaderInput{name: name, location: uint32(location), glslType: glslType})
+	}
+	return inputs
+}
+
+// the shape of a glsl input type
+type glslShape struct {
+	name       string
+	components int32
+	columns    int32 //more than 1 for matrices, each column takes a location
+	integer    bool
+}
+
+var glslShapes = map[uint32]glslShape{
+	gl.FLOAT:             {"float", 1, 1, false},
+	gl.FLOAT_VEC2:        {"vec2", 2, 1, false},
+	gl.FLOAT_VEC3:        {"vec3", 3, 1, false},
+	gl.FLOAT_VEC4:        {"vec4", 4, 1, false},
+	gl.FLOAT_MAT2:        {"mat2", 2, 2, false},
+	gl.FLOAT_MAT3:        {"mat3", 3, 3, false},
+	gl.FLOAT_MAT4:        {"mat4", 4, 4, false},
+	gl.INT:               {"int", 1, 1, true},
+	gl.INT_VEC2:          {"ivec2", 2, 1, true},
+	gl.INT_VEC3:          {"ivec3", 3, 1, true},
+	gl.INT_VEC4:          {"ivec4", 4, 1, true},
+	gl.UNSIGNED_INT:      {"uint", 1, 1, true},
+	gl.UNSIGNED_INT_VEC2: {"uvec2", 2, 1, true},
+	gl.UNSIGNED_INT_VEC3: {"uvec3", 3, 1, true},
+	gl.UNSIGNED_INT_VEC4: {"uvec4", 4, 1, true},
+}
+
+func shapeOf(input shaderInput) (glslShape, error) {
+	shape, ok := glslShapes[input.glslType]
+	if !ok {
+		return glslShape{}, fmt.Errorf("input %s has an unsupported type (0x%x)", input.name, input.glslType)
+	}
+	return shape, nil
+}
+
+// checks the attribute can feed the input, fewer components are only
+// allowed for a vec4 as GL fills the rest in with (0, 0, 0, 1)
+func checkAttribute(input shaderInput, shape glslShape, a VertexAttribute) error {
+	describe := func() string {
+		s := fmt.Sprintf("%d %v", a.Components, a.Type)
+		switch {
+		case a.Normalized:
+			s += " (normalized)"
+		case a.Integer:
+			s += " (integer)"
+		}
+		return s
+	}
+
+	switch {
+	case shape.columns > 1:
+		return fmt.Errorf("input %s is %s, only instance data can fill matrices", input.name, shape.name)
+	case shape.integer && !a.Integer:
+		return fmt.Errorf("input %s is %s but the vertex gives %s, tag it with ,integer", input.name, shape.name, describe())
+	case !shape.integer && a.Integer:
+		return fmt.Errorf("input %s is %s but the vertex gives %s, it needs to be an int type", input.name, shape.name, describe())
+	case a.Components != shape.components && !(shape.components == 4 && a.Components < 4):
+		return fmt.Errorf("input %s is %s but the vertex gives %s", input.name, shape.name, describe())
+	}
+	return nil
+}
+
+// the attribute of the layout feeding the input, -1 if there isn't one
+func (l VertexLayout) attributeFor(input shaderInput) int {
+	semantic := semanticOf(input.name)
+	for i := range l.Attributes {
+		if l.names != nil && l.names[i] != "" {
+			if l.names[i] == semantic {
+				return i
+			}
+		} else if l.locations != nil && l.locations[i] == input.location {
+			return i
+		}
+	}
+	return -1
+}
+
+var (
+	programLinks     = make(map[ProgramID]uint64) //program -> which link made it
+	programLinkCount uint64
+)
+
+// GL reuses the ids of deleted programs so each link is counted
+// to tell a reloaded program apart from the one it replaced
+func noteProgramLinked(id ProgramID) {
+	programLinkCount++
+	programLinks[id] = programLinkCount
+}
+
+type vaoKey struct {
+	program   ProgramID
+	instanced bool
+}
+
+type objectVAO struct {
+	id   BufferID
+	link uint64
+}
+
+// binds the VAO that feeds this object's verticies into the shader's inputs
+// making it the first time the shader is used
+func (o Object) Bind(shader *Shader) error {
+	return o.bind(shader, false)
+}
+
+// the draws that have failed to bind, so each is only logged once
+type bindFailure struct {
+	vbo     BufferID
+	program ProgramID
+	link    uint64
+}
+
+var bindFailures = make(map[bindFailure]bool)
+
+// binds for a draw, false if it should be skipped
+// a reloaded shader can add inputs the object can't feed so this logs
+// the error the first time instead of stopping the app
+func (o Object) bindForDraw(shader *Shader, instanced bool) bool {
+	err := o.bind(shader, instanced)
+	if err == nil {
+		return true
+	}
+	key := bindFailure{o.vbo, shader.id, programLinks[shader.id]}
+	if !bindFailures[key] {
+		bindFailures[key] = true
+		fmt.Printf("Skipping draws: %v\n", err)
+	}
+	return false
+}
+
+func (o Object) bind(shader *Shader, instanced bool) error {
+	if o.vbo == 0 || o.vaos == nil {
+		return fmt.Errorf("object has no buffers, it was deleted or never uploaded")
+	}
+	key := vaoKey{shader.id, instanced}
+	link := programLinks[shader.id]
+	if vao, ok := o.vaos[key]; ok {
+		if vao.link == link {
+			BindVertexArray(vao.id)
+			return nil
+		}
+		DeleteVertexArray(vao.id) //made for a deleted program with the same id
+		delete(o.vaos, key)
+	}
+
+	vao := GenBindVertexArray()
+	if err := o.setupAttributes(shader, instanced); err != nil {
+		BindVertexArray(0)
+		DeleteVertexArray(vao)
+		return fmt.Errorf("shader (%s): %w", shader.Name(), err)
+	}
+	o.vaos[key] = objectVAO{id: vao, link: link}
+	return nil
+}
+
+// points each of the program's inputs at the vertex or instance buffer
+// with the bound VAO
+func (o Object) setupAttributes(shader *Shader, instanced bool) error {
+	for _, input := range activeInputs(shader.id) {
+		shape, err := shapeOf(input)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := instanceInputs[input.name]; ok {
+			if !instanced {
+				return fmt.Errorf("input %s is per instance, draw with DrawInstanced", input.name)
+			}
+			if err := setupInstanceInput(o.instanceBuffer, input, shape); err != nil {
+				return err
+			}
+			continue
+		}
+
+		i := o.layout.attributeFor(input)
+		if i < 0 {
+			return fmt.Errorf("input %s (%s) has no matching vertex attribute, the vertex has %s",
+				input.name, shape.name, strings.Join(o.layout.describeNames(), ", "))
+		}
+		if err := checkAttribute(input, shape, o.layout.Attributes[i]); err != nil {
+			return err
+		}
+		backend.BindBuffer(gl.ARRAY_BUFFER, uint32(o.vbo))
+		vertexAttribPointer(input.location, o.layout.Attributes[i], o.layout.Stride(), o.layout.Offset(i))
+	}
+
+	if o.ebo != 0 {
+		backend.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, uint32(o.ebo)) //the element buffer binding is stored in the VAO
+	}
+	return nil
+}
+
+func (l VertexLayout) describeNames() []string {
+	names := make([]string, len(l.Attributes))
+	for i := range names {
+		if l.names != nil && l.names[i] != "" {
+			names[i] = l.names[i]
+		} else {
+			names[i] = "location " + strconv.Itoa(int(l.locations[i]))
+		}
+	}
+	return names
+}
+
+// the instance attributes DrawInstanced uploads
+type instanceInput struct {
+	offset uintptr
+	shape  glslShape
+}
+
+// the types are in glsl.InstanceInputs so cmd/shaderlint can check them
+var instanceInputs = map[string]instanceInput{
+	"aInstanceModel":   {unsafe.Offsetof(InstanceData{}.Model), instanceShape("aInstanceModel")},
+	"aInstanceColor":   {unsafe.Offsetof(InstanceData{}.Color), instanceShape("aInstanceColor")},
+	"aInstanceTexture": {unsafe.Offsetof(InstanceData{}.TextureIndex), instanceShape("aInstanceTexture")},
+}
+
+func instanceShape(input string) glslShape {
+	for _, shape := range glslShapes {
+		if shape.name == glsl.InstanceInputs[input] {
+			return shape
+		}
+	}
+	panic(fmt.Errorf("instance input %s has no glsl type", input))
+}
+
+// sets the input to advance once per instance
+// a matrix takes up one location per column
+func setupInstanceInput(instanceBuffer BufferID, input shaderInput, shape glslShape) error {
+	expected := instanceInputs[input.name]
+	if shape != expected.shape {
+		return fmt.Errorf("input %s is %s but instance data gives %s", input.name, shape.name, expected.shape.name)
+	}
+
+	backend.BindBuffer(gl.ARRAY_BUFFER, uint32(instanceBuffer))
+	stride := int32(unsafe.Sizeof(InstanceData{}))
+	for col := uint32(0); col < uint32(shape.columns); col++ {
+		location := input.location + col
+		offset := expected.offset + uintptr(col*uint32(shape.components)*4)
+		backend.VertexAttribPointer(location, shape.components, gl.FLOAT, false, stride, offset)
+		backend.EnableVertexAttribArray(location)
+		backend.VertexAttribDivisor(location, 1)
+	}
+	return nil
+}
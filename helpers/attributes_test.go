@@ -0,0 +1,83 @@
+package helpers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/moltenwolfcub/OpenGLGoLearning/helpers/glsl"
+)
+
+// shaders are checked against the glsl tables without GL so they have to
+// say what Object really uploads
+func TestInputTablesMatchUploads(t *testing.T) {
+	layout := VertexLayoutOf[meshVertex]()
+	uploaded := make(map[string]bool)
+	for i, semantic := range layout.names {
+		uploaded[semantic] = true
+		glslType, ok := glsl.MeshAttributes[semantic]
+		if !ok {
+			t.Errorf("meshVertex has a %s but glsl.MeshAttributes hasn't", semantic)
+			continue
+		}
+		a := layout.Attributes[i]
+		var shape glslShape
+		for _, s := range glslShapes {
+			if s.name == glslType {
+				shape = s
+			}
+		}
+		if a.Type != Float32Attrib || a.Integer || a.Components != shape.components {
+			t.Errorf("meshVertex gives the %s as %d %v, glsl.MeshAttributes says %s", semantic, a.Components, a.Type, glslType)
+		}
+	}
+	for semantic := range glsl.MeshAttributes {
+		if !uploaded[semantic] {
+			t.Errorf("glsl.MeshAttributes has a %s but meshVertex hasn't", semantic)
+		}
+		if _, ok := glsl.SemanticInputs[semantic]; !ok {
+			t.Errorf("glsl.SemanticInputs has no input names for the %s", semantic)
+		}
+	}
+
+	if len(instanceInputs) != len(glsl.InstanceInputs) {
+		t.Errorf("DrawInstanced fills %d inputs but glsl.InstanceInputs has %d", len(instanceInputs), len(glsl.InstanceInputs))
+	}
+	fields := reflect.TypeOf(InstanceData{})
+	for name, input := range instanceInputs {
+		var field reflect.StructField
+		for i := 0; i < fields.NumField(); i++ {
+			if fields.Field(i).Offset == input.offset {
+				field = fields.Field(i)
+			}
+		}
+		//every InstanceData field is float32s
+		floats := uintptr(input.shape.components * input.shape.columns)
+		if input.shape.integer || field.Type == nil || field.Type.Size() != floats*4 {
+			t.Errorf("DrawInstanced fills %s from InstanceData.%s but glsl.InstanceInputs says it's %s", name, field.Name, glsl.InstanceInputs[name])
+		}
+	}
+}
+
+func TestDeletedObjectIsntDrawn(t *testing.T) {
+	b := useRecordingBackend(t)
+	shader, _ := newTestShader(t, testVertSource, testFragSource)
+	//with no inputs there is nothing to fail on before the VAO is stored
+	noInputs, _ := newTestShader(t, "#version 330 core\nvoid main() {\n\tgl_Position = vec4(0.0);\n}\n", "#version 330 core\nout vec4 FragColor;\nvoid main() {\n\tFragColor = vec4(1.0);\n}\n")
+
+	cube := Cube(1)
+	cube.Delete()
+	var zero Object
+	for _, o := range []Object{cube, zero} {
+		for _, s := range []*Shader{shader, noInputs} {
+			s.Use()
+			if err := o.Bind(s); err == nil {
+				t.Errorf("binding an object without buffers to %s didn't give an error", s.Name())
+			}
+			o.Draw(s, mgl32.Ident4())
+		}
+	}
+	if len(b.Draws) != 0 {
+		t.Errorf("made %d draws without buffers", len(b.Draws))
+	}
+}
@@ -0,0 +1,198 @@
+package helpers
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+/*
+Static batching merges meshes that never move into one Object with
+their transforms already applied to the verticies. Entries that share
+a material are put next to each other in the index buffer so the whole
+batch is drawn with one call per material e.g.
+
+	batch := helpers.NewStaticBatch[helpers.TextureID]()
+	batch.Add(helpers.CubeMesh(1), mgl32.Translate3D(0, 5, 0), metal)
+	batch.Add(helpers.PentahedronMesh(2), mgl32.Translate3D(0, 1, 0), metal)
+
+	batch.Draw(shader, func(texture helpers.TextureID) {
+		helpers.BindTextureUnit(0, texture)
+	})
+
+M is whatever the caller binds between draws, a texture, a *Material or
+a struct of both.
+*/
+
+type batchEntry[M comparable] struct {
+	mesh      *MeshData
+	transform mgl32.Mat4
+	material  M
+	hidden    bool
+}
+
+// the part of the index buffer drawn with one material
+type batchRange[M comparable] struct {
+	material M
+	first    int //first index
+	count    int
+	bounds   AABB //in world space
+	sphere   BoundingSphere
+}
+
+type StaticBatch[M comparable] struct {
+	entries []*batchEntry[M] //nil once removed so handles stay valid
+	object  Object
+	ranges  []batchRange[M]
+	dirty   bool
+}
+
+func NewStaticBatch[M comparable]() *StaticBatch[M] {
+	b := StaticBatch[M]{}
+	return &b
+}
+
+// adds a copy of the mesh and returns a handle for Remove and SetTransform
+// the batch is rebuilt on the next Draw
+func (b *StaticBatch[M]) Add(mesh *MeshData, transform mgl32.Mat4, material M) int {
+	if err := mesh.Validate(); err != nil {
+		panic(err)
+	}
+	b.entries = append(b.entries, &batchEntry[M]{mesh: mesh.Clone(), transform: transform, material: material})
+	b.dirty = true
+	return len(b.entries) - 1
+}
+
+// the entry for a handle, nil if it was removed or isn't from this batch
+func (b *StaticBatch[M]) entry(handle int) *batchEntry[M] {
+	if handle < 0 || handle >= len(b.entries) {
+		return nil
+	}
+	return b.entries[handle]
+}
+
+// handles that were removed or cleared are ignored by Remove, SetTransform, SetMaterial and SetVisible
+func (b *StaticBatch[M]) Remove(handle int) {
+	if b.entry(handle) == nil {
+		return
+	}
+	b.entries[handle] = nil
+	b.dirty = true
+}
+
+func (b *StaticBatch[M]) SetTransform(handle int, transform mgl32.Mat4) {
+	if e := b.entry(handle); e != nil {
+		e.transform = transform
+		b.dirty = true
+	}
+}
+
+func (b *StaticBatch[M]) SetMaterial(handle int, material M) {
+	if e := b.entry(handle); e != nil {
+		e.material = material
+		b.dirty = true
+	}
+}
+
+// hidden entries are left out of the batch until they are shown again
+func (b *StaticBatch[M]) SetVisible(handle int, visible bool) {
+	if e := b.entry(handle); e != nil && e.hidden == visible {
+		e.hidden = !visible
+		b.dirty = true
+	}
+}
+
+// removes every entry, handles from before aren't valid afterwards
+func (b *StaticBatch[M]) Clear() {
+	b.entries = nil
+	b.dirty = true
+}
+
+// the number of draw calls an unculled Draw makes
+func (b *StaticBatch[M]) DrawCalls() int {
+	b.Rebuild()
+	return len(b.ranges)
+}
+
+// re-merges the entries and uploads them if anything changed since the last build
+// Draw calls this so it's only needed to move the work out of a frame
+func (b *StaticBatch[M]) Rebuild() {
+	if !b.dirty {
+		return
+	}
+	b.dirty = false
+
+	//entries are grouped by material in the order each material was first added
+	var materials []M
+	groups := make(map[M][]*batchEntry[M])
+	for _, e := range b.entries {
+		if e == nil || e.hidden {
+			continue
+		}
+		if _, ok := groups[e.material]; !ok {
+			materials = append(materials, e.material)
+		}
+		groups[e.material] = append(groups[e.material], e)
+	}
+
+	merged := &MeshData{}
+	b.ranges = b.ranges[:0]
+	for _, material := range materials {
+		group := &MeshData{}
+		for _, e := range groups[material] {
+			m := e.mesh.Clone()
+			m.prepareUpload() //so every mesh in the merge has normals and tangents
+			m.Transform(e.transform)
+			m.ensureIndexed()
+			group.Merge(m)
+		}
+
+		b.ranges = append(b.ranges, batchRange[M]{
+			material: material,
+			first:    len(merged.Indices),
+			count:    len(group.Indices),
+			bounds:   group.Bounds(),
+			sphere:   group.BoundingSphere(),
+		})
+		merged.Merge(group)
+	}
+
+	if merged.VertexCount() == 0 {
+		b.object.Delete()
+		return
+	}
+	if b.object.vbo == 0 {
+		b.object = merged.Upload()
+	} else {
+		b.object.UpdateMesh(merged)
+	}
+}
+
+// draws each material's range with the verticies already in world space
+// bindMaterial is called before each range and can be nil
+func (b *StaticBatch[M]) Draw(shader *Shader, bindMaterial func(M)) {
+	b.Rebuild()
+	if len(b.ranges) == 0 {
+		return
+	}
+	if !b.object.bindForDraw(shader, false) {
+		return
+	}
+	shader.SetMatrix4("model", mgl32.Ident4())
+
+	for _, r := range b.ranges {
+		if activeCuller != nil && !activeCuller.visibleBounds(r.sphere, r.bounds) {
+			continue
+		}
+		if bindMaterial != nil {
+			bindMaterial(r.material)
+		}
+		b.object.drawRange(r.first, r.count)
+		countDrawn(1)
+	}
+}
+
+// frees the batch's buffers, the entries are kept so it can be rebuilt
+func (b *StaticBatch[M]) Delete() {
+	b.object.Delete()
+	b.ranges = nil
+	b.dirty = true
+}
@@ -0,0 +1,138 @@
+package helpers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// the first index and index count of each draw since the last call
+// with the material bound before it
+type batchDraw struct {
+	material     string
+	first, count int
+}
+
+func drawBatch(b *RecordingBackend, batch *StaticBatch[string], shader *Shader) []batchDraw {
+	before := len(b.Draws)
+	var bound []string
+	batch.Draw(shader, func(material string) {
+		bound = append(bound, material)
+	})
+
+	var draws []batchDraw
+	for i, d := range b.Draws[before:] {
+		draws = append(draws, batchDraw{material: bound[i], first: int(d.Offset / 4), count: int(d.Count)})
+	}
+	return draws
+}
+
+func TestStaticBatchDraws(t *testing.T) {
+	b := useRecordingBackend(t)
+	shader, _ := newTestShader(t, testVertSource, testFragSource)
+	shader.Use()
+
+	cube, penta := CubeMesh(1), PentahedronMesh(1)
+	c, p := cube.ElementCount(), penta.ElementCount()
+
+	batch := NewStaticBatch[string]()
+	defer batch.Delete()
+	first := batch.Add(cube, mgl32.Ident4(), "metal")
+	second := batch.Add(cube, mgl32.Translate3D(2, 0, 0), "wood")
+	third := batch.Add(penta, mgl32.Translate3D(4, 0, 0), "metal")
+
+	steps := []struct {
+		name   string
+		change func()
+		want   []batchDraw
+	}{
+		{
+			//entries with the same material are drawn together
+			name:   "added",
+			change: func() {},
+			want:   []batchDraw{{"metal", 0, c + p}, {"wood", c + p, c}},
+		},
+		{
+			//materials go in the order the remaining entries were added
+			name:   "first removed",
+			change: func() { batch.Remove(first) },
+			want:   []batchDraw{{"wood", 0, c}, {"metal", c, p}},
+		},
+		{
+			name:   "hidden",
+			change: func() { batch.SetVisible(third, false) },
+			want:   []batchDraw{{"wood", 0, c}},
+		},
+		{
+			name:   "shown again",
+			change: func() { batch.SetVisible(third, true) },
+			want:   []batchDraw{{"wood", 0, c}, {"metal", c, p}},
+		},
+		{
+			name:   "material changed",
+			change: func() { batch.SetMaterial(second, "metal") },
+			want:   []batchDraw{{"metal", 0, c + p}},
+		},
+		{
+			name:   "everything hidden",
+			change: func() { batch.SetVisible(second, false); batch.SetVisible(third, false) },
+			want:   nil,
+		},
+	}
+	for _, step := range steps {
+		step.change()
+		if got := drawBatch(b, batch, shader); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: got draws %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestStaticBatchHandles(t *testing.T) {
+	b := useRecordingBackend(t)
+	shader, _ := newTestShader(t, testVertSource, testFragSource)
+	shader.Use()
+	cube := CubeMesh(1)
+	c := cube.ElementCount()
+
+	batch := NewStaticBatch[string]()
+	defer batch.Delete()
+	removed := batch.Add(cube, mgl32.Ident4(), "metal")
+	kept := batch.Add(cube, mgl32.Ident4(), "wood")
+	batch.Remove(removed)
+
+	//handles aren't reused so the removed one can't reach the new entry
+	added := batch.Add(cube, mgl32.Ident4(), "stone")
+	if added == removed || added == kept {
+		t.Fatalf("Add gave handle %d which was already used", added)
+	}
+	drawBatch(b, batch, shader)
+
+	for _, handle := range []int{removed, -1, 99} {
+		batch.Remove(handle)
+		batch.SetTransform(handle, mgl32.Translate3D(1, 0, 0))
+		batch.SetMaterial(handle, "glass")
+		batch.SetVisible(handle, false)
+	}
+	if batch.dirty {
+		t.Error("changes through invalid handles made the batch rebuild")
+	}
+	want := []batchDraw{{"wood", 0, c}, {"stone", c, c}}
+	if got := drawBatch(b, batch, shader); !reflect.DeepEqual(got, want) {
+		t.Errorf("got draws %v, want %v", got, want)
+	}
+
+	//setting what it already is doesn't rebuild either
+	batch.SetVisible(kept, true)
+	if batch.dirty {
+		t.Error("showing a visible entry made the batch rebuild")
+	}
+
+	batch.Clear()
+	if got := drawBatch(b, batch, shader); got != nil {
+		t.Errorf("a cleared batch made draws %v", got)
+	}
+	if n := batch.DrawCalls(); n != 0 {
+		t.Errorf("a cleared batch needs %d draw calls", n)
+	}
+}
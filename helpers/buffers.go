@@ -13,31 +13,55 @@ type BufferID uint32
 // used for generating and binding general buffers
 // e.g. VertexBufferObject or NormalArrayObject
 func GenBindBuffer(target uint32) BufferID {
-	var buffer uint32
-	gl.GenBuffers(1, &buffer)
-	gl.BindBuffer(target, buffer)
+	buffer := backend.GenBuffer()
+	backend.BindBuffer(target, buffer)
+	trackResource(bufferResource, buffer)
 	return BufferID(buffer)
 }
 
 // used for generating and binding vertex buffers
 func GenBindVertexArray() BufferID {
-	var VAO uint32
-	gl.GenVertexArrays(1, &VAO)
-	gl.BindVertexArray(VAO)
+	VAO := backend.GenVertexArray()
+	backend.BindVertexArray(VAO)
+	trackResource(vertexArrayResource, VAO)
 	return BufferID(VAO)
 }
 
+// frees a buffer made by GenBindBuffer, 0 is ignored
+func DeleteBuffer(id BufferID) {
+	if id == 0 {
+		return
+	}
+	buffer := uint32(id)
+	backend.DeleteBuffer(buffer)
+	untrackResource(bufferResource, buffer)
+}
+
+// frees a vertex array made by GenBindVertexArray, 0 is ignored
+func DeleteVertexArray(id BufferID) {
+	if id == 0 {
+		return
+	}
+	VAO := uint32(id)
+	backend.DeleteVertexArray(VAO)
+	untrackResource(vertexArrayResource, VAO)
+}
+
 func BindVertexArray(id BufferID) {
-	gl.BindVertexArray(uint32(id))
+	backend.BindVertexArray(uint32(id))
 }
 
 // used for initialising the target with the go array at data
 // a more go-esque wrapper for the gl.BufferData function
 func BufferData[T any](target uint32, data []T, usage uint32) {
+	if len(data) == 0 {
+		backend.BufferData(target, 0, nil, usage) //gl.Ptr can't point into an empty slice
+		return
+	}
 	var v T
 	dataTypeSize := unsafe.Sizeof(v)
 
-	gl.BufferData(target, len(data)*int(dataTypeSize), gl.Ptr(data), usage)
+	backend.BufferData(target, len(data)*int(dataTypeSize), gl.Ptr(data), usage)
 }
 
 // change to interface
@@ -74,15 +98,28 @@ func (b BufferLayout[T]) offset(index int) uintptr {
 	}
 	return uintptr(offset * b.dataSize())
 }
-func (b BufferLayout[T]) getXType() uint32 {
+
+// the attribute type matching T, all the segments of a BufferLayout share it
+func (b BufferLayout[T]) attribType() AttribType {
 	var v T
 	i := reflect.TypeOf(v).Kind()
 	switch i {
 	case reflect.Float32:
-		return gl.FLOAT
+		return Float32Attrib
+	case reflect.Int8:
+		return Int8Attrib
+	case reflect.Uint8:
+		return Uint8Attrib
+	case reflect.Int16:
+		return Int16Attrib
+	case reflect.Uint16:
+		return Uint16Attrib
+	case reflect.Int32:
+		return Int32Attrib
+	case reflect.Uint32:
+		return Uint32Attrib
 	default:
-		panic(fmt.Errorf("unsupported type (%v) for generating xtype", i))
-		//I've only implemented the one's I've had to use
+		panic(fmt.Errorf("unsupported type (%v) for a buffer layout", i))
 	}
 }
 
@@ -95,20 +132,44 @@ func NewBufferLoader() *BufferLoader {
 	return &b
 }
 
-func (b *BufferLoader) BuildFloatBuffer(id BufferID, layout BufferLayout[float32]) {
+// uploads the floats to the bound ARRAY_BUFFER and points the next
+// len(layout.segments) attribute locations of the vao at them
+func (b *BufferLoader) BuildFloatBuffer(vao BufferID, layout BufferLayout[float32]) {
 	BufferData(gl.ARRAY_BUFFER, layout.data, gl.STATIC_DRAW)
 
-	BindVertexArray(id)
+	BindVertexArray(vao)
 
 	for i, s := range layout.segments {
-		index := b.layoutIndex + uint32(i)
-		gl.VertexAttribPointerWithOffset(index, s, layout.getXType(), false, layout.calcStride(), layout.offset(i))
-		gl.EnableVertexAttribArray(index)
+		a := VertexAttribute{Type: layout.attribType(), Components: s}
+		vertexAttribPointer(b.layoutIndex+uint32(i), a, layout.calcStride(), layout.offset(i))
 	}
 
 	b.layoutIndex += uint32(len(layout.segments))
 }
 
+// the mixed type version of BuildFloatBuffer, data is usually made by a VertexWriter
+func (b *BufferLoader) BuildBuffer(vao BufferID, layout VertexLayout, data []byte) {
+	if len(data)%int(layout.Stride()) != 0 {
+		panic(fmt.Errorf("buffer of %d bytes isn't a whole number of %d byte verticies", len(data), layout.Stride()))
+	}
+	layout.requireLocations()
+	BufferData(gl.ARRAY_BUFFER, data, gl.STATIC_DRAW)
+
+	BindVertexArray(vao)
+
+	for i, a := range layout.Attributes {
+		location := b.layoutIndex + uint32(i)
+		if layout.locations != nil {
+			location = layout.locations[i]
+		}
+		vertexAttribPointer(location, a, layout.Stride(), layout.Offset(i))
+	}
+
+	if layout.locations == nil {
+		b.layoutIndex += uint32(len(layout.Attributes))
+	}
+}
+
 // VAO := helpers.GenBindVertexArray()
 // helpers.BufferData(gl.ARRAY_BUFFER, verticies, gl.STATIC_DRAW)
 
@@ -0,0 +1,186 @@
+package helpers
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// an axis aligned bounding box
+type AABB struct {
+	Min mgl32.Vec3
+	Max mgl32.Vec3
+}
+
+func (b AABB) Center() mgl32.Vec3 {
+	return b.Min.Add(b.Max).Mul(0.5)
+}
+
+func (b AABB) Extents() mgl32.Vec3 {
+	return b.Max.Sub(b.Min).Mul(0.5)
+}
+
+// the box that contains this one after it has been transformed
+func (b AABB) Transform(m mgl32.Mat4) AABB {
+	//arvo's method, each axis of the matrix grows the box independently
+	center := mgl32.TransformCoordinate(b.Center(), m)
+	extents := b.Extents()
+
+	var newExtents mgl32.Vec3
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			newExtents[row] += mgl32.Abs(m.At(row, col)) * extents[col]
+		}
+	}
+	return AABB{
+		Min: center.Sub(newExtents),
+		Max: center.Add(newExtents),
+	}
+}
+
+type BoundingSphere struct {
+	Center mgl32.Vec3
+	Radius float32
+}
+
+// the sphere after it has been transformed, non uniform
+// scales use the largest axis so the result still contains the mesh
+func (s BoundingSphere) Transform(m mgl32.Mat4) BoundingSphere {
+	scale := max(m.Col(0).Vec3().Len(), m.Col(1).Vec3().Len(), m.Col(2).Vec3().Len())
+	return BoundingSphere{
+		Center: mgl32.TransformCoordinate(s.Center, m),
+		Radius: s.Radius * scale,
+	}
+}
+
+func (m *MeshData) Bounds() AABB {
+	if m.VertexCount() == 0 {
+		return AABB{}
+	}
+
+	b := AABB{Min: m.Positions[0], Max: m.Positions[0]}
+	for _, p := range m.Positions[1:] {
+		for i := range p {
+			b.Min[i] = min(b.Min[i], p[i])
+			b.Max[i] = max(b.Max[i], p[i])
+		}
+	}
+	return b
+}
+
+// a sphere around the centre of the bounding box, this isn't the smallest
+// possible sphere but it is cheap and close enough for culling
+func (m *MeshData) BoundingSphere() BoundingSphere {
+	center := m.Bounds().Center()
+
+	var radius float32
+	for _, p := range m.Positions {
+		radius = max(radius, p.Sub(center).Len())
+	}
+	return BoundingSphere{Center: center, Radius: radius}
+}
+
+// a plane where points in front satisfy Normal.Dot(p) + D >= 0
+type FrustumPlane struct {
+	Normal mgl32.Vec3
+	D      float32
+}
+
+func (p FrustumPlane) Distance(point mgl32.Vec3) float32 {
+	return p.Normal.Dot(point) + p.D
+}
+
+type Frustum struct {
+	Planes [6]FrustumPlane //left, right, bottom, top, near, far all facing inwards
+}
+
+// extracts the frustum planes from a projection * view matrix
+// using the Gribb/Hartmann method, the planes are in world space
+func NewFrustum(viewProj mgl32.Mat4) Frustum {
+	r0, r1, r2, r3 := viewProj.Row(0), viewProj.Row(1), viewProj.Row(2), viewProj.Row(3)
+
+	f := Frustum{}
+	for i, v := range [6]mgl32.Vec4{
+		r3.Add(r0), r3.Sub(r0),
+		r3.Add(r1), r3.Sub(r1),
+		r3.Add(r2), r3.Sub(r2),
+	} {
+		length := v.Vec3().Len()
+		f.Planes[i] = FrustumPlane{Normal: v.Vec3().Mul(1 / length), D: v.W() / length}
+	}
+	return f
+}
+
+func (f Frustum) IntersectsSphere(s BoundingSphere) bool {
+	for _, p := range f.Planes {
+		if p.Distance(s.Center) < -s.Radius {
+			return false
+		}
+	}
+	return true
+}
+
+func (f Frustum) IntersectsAABB(b AABB) bool {
+	for _, p := range f.Planes {
+		//the corner furthest along the plane's normal
+		corner := b.Min
+		for i := range corner {
+			if p.Normal[i] >= 0 {
+				corner[i] = b.Max[i]
+			}
+		}
+		if p.Distance(corner) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// skips draws of objects that are outside the camera's view
+// and counts how many were drawn or culled since the last Update
+// a visible object is only counted as drawn once its draw is made
+type Culler struct {
+	Frustum Frustum
+	Drawn   int
+	Culled  int
+}
+
+func NewCuller() *Culler {
+	c := Culler{}
+	return &c
+}
+
+// sets the frustum for a new frame and resets the counts
+func (c *Culler) Update(proj, view mgl32.Mat4) {
+	c.Frustum = NewFrustum(proj.Mul4(view))
+	c.Drawn = 0
+	c.Culled = 0
+}
+
+// tests the cheap sphere first and only checks the tighter box if that passes
+// objects that aren't visible are counted as culled
+func (c *Culler) Visible(o Object, drawMatrix mgl32.Mat4) bool {
+	return c.visibleBounds(o.sphere.Transform(drawMatrix), o.bounds.Transform(drawMatrix))
+}
+
+// the same test for bounds that are already in world space
+func (c *Culler) visibleBounds(sphere BoundingSphere, box AABB) bool {
+	visible := c.Frustum.IntersectsSphere(sphere) && c.Frustum.IntersectsAABB(box)
+	if !visible {
+		c.Culled++
+	}
+	return visible
+}
+
+// called by the draw functions after they have made their draws
+func countDrawn(n int) {
+	if activeCuller != nil {
+		activeCuller.Drawn += n
+	}
+}
+
+var activeCuller *Culler
+
+// the culler Object.Draw and DrawMultiple check before drawing
+// nil (the default) draws everything
+func UseCuller(c *Culler) {
+	activeCuller = c
+}
@@ -0,0 +1,159 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// a box from -1 to 1 in X and Y looking down -Z from 0.1 to 10
+var testOrtho = mgl32.Ortho(-1, 1, -1, 1, 0.1, 10)
+
+func TestNewFrustum(t *testing.T) {
+	r := float32(1 / 1.4142135)
+	tests := []struct {
+		name     string
+		viewProj mgl32.Mat4
+		want     [6]FrustumPlane
+	}{
+		{
+			name:     "orthographic",
+			viewProj: testOrtho,
+			want: [6]FrustumPlane{
+				{Normal: mgl32.Vec3{1, 0, 0}, D: 1},
+				{Normal: mgl32.Vec3{-1, 0, 0}, D: 1},
+				{Normal: mgl32.Vec3{0, 1, 0}, D: 1},
+				{Normal: mgl32.Vec3{0, -1, 0}, D: 1},
+				{Normal: mgl32.Vec3{0, 0, -1}, D: -0.1},
+				{Normal: mgl32.Vec3{0, 0, 1}, D: 10},
+			},
+		},
+		{
+			//the side planes of a 90 degree frustum go through the eye at 45 degrees
+			name:     "perspective",
+			viewProj: mgl32.Perspective(mgl32.DegToRad(90), 1, 0.1, 10),
+			want: [6]FrustumPlane{
+				{Normal: mgl32.Vec3{r, 0, -r}},
+				{Normal: mgl32.Vec3{-r, 0, -r}},
+				{Normal: mgl32.Vec3{0, r, -r}},
+				{Normal: mgl32.Vec3{0, -r, -r}},
+				{Normal: mgl32.Vec3{0, 0, -1}, D: -0.1},
+				{Normal: mgl32.Vec3{0, 0, 1}, D: 10},
+			},
+		},
+		{
+			//the planes come out in world space
+			name:     "moved camera",
+			viewProj: testOrtho.Mul4(mgl32.Translate3D(-5, 0, 0)),
+			want: [6]FrustumPlane{
+				{Normal: mgl32.Vec3{1, 0, 0}, D: -4},
+				{Normal: mgl32.Vec3{-1, 0, 0}, D: 6},
+				{Normal: mgl32.Vec3{0, 1, 0}, D: 1},
+				{Normal: mgl32.Vec3{0, -1, 0}, D: 1},
+				{Normal: mgl32.Vec3{0, 0, -1}, D: -0.1},
+				{Normal: mgl32.Vec3{0, 0, 1}, D: 10},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFrustum(tt.viewProj)
+			for i, p := range f.Planes {
+				want := tt.want[i]
+				if !p.Normal.ApproxEqualThreshold(want.Normal, 1e-5) || !mgl32.FloatEqualThreshold(p.D, want.D, 1e-4) {
+					t.Errorf("plane %d got %v, want %v", i, p, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFrustumIntersects(t *testing.T) {
+	f := NewFrustum(testOrtho)
+
+	tests := []struct {
+		name   string
+		sphere BoundingSphere
+		want   bool
+	}{
+		{"inside", BoundingSphere{mgl32.Vec3{0, 0, -5}, 0.5}, true},
+		{"outside to the right", BoundingSphere{mgl32.Vec3{3, 0, -5}, 0.5}, false},
+		{"behind the camera", BoundingSphere{mgl32.Vec3{0, 0, 5}, 1}, false},
+		{"past the far plane", BoundingSphere{mgl32.Vec3{0, 0, -12}, 1}, false},
+		{"straddling the left plane", BoundingSphere{mgl32.Vec3{-1, 0, -5}, 0.5}, true},
+		{"straddling the near plane", BoundingSphere{mgl32.Vec3{0, 0, 0}, 0.5}, true},
+		{"touching the right plane", BoundingSphere{mgl32.Vec3{1.5, 0, -5}, 0.5}, true},
+		{"bigger than the frustum", BoundingSphere{mgl32.Vec3{0, 0, -5}, 100}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := f.IntersectsSphere(tt.sphere); got != tt.want {
+				t.Errorf("sphere got %v, want %v", got, tt.want)
+			}
+			//the cube that fits around the sphere
+			r := mgl32.Vec3{tt.sphere.Radius, tt.sphere.Radius, tt.sphere.Radius}
+			box := AABB{Min: tt.sphere.Center.Sub(r), Max: tt.sphere.Center.Add(r)}
+			if got := f.IntersectsAABB(box); got != tt.want {
+				t.Errorf("box %v got %v, want %v", box, got, tt.want)
+			}
+		})
+	}
+
+	//a long box through the middle has no corners inside but still crosses it
+	through := AABB{Min: mgl32.Vec3{-50, -0.1, -5}, Max: mgl32.Vec3{50, 0.1, -4}}
+	if !f.IntersectsAABB(through) {
+		t.Error("a box crossing the whole frustum was culled")
+	}
+}
+
+func TestAABBTransform(t *testing.T) {
+	b := AABB{Min: mgl32.Vec3{-1, -1, -1}, Max: mgl32.Vec3{1, 1, 1}}
+
+	moved := b.Transform(mgl32.Translate3D(5, 0, 0).Mul4(mgl32.Scale3D(2, 1, 1)))
+	if want := (AABB{Min: mgl32.Vec3{3, -1, -1}, Max: mgl32.Vec3{7, 1, 1}}); moved != want {
+		t.Errorf("got %v, want %v", moved, want)
+	}
+
+	//a 45 degree turn makes the box wider by the diagonal
+	turned := b.Transform(mgl32.HomogRotate3DY(mgl32.DegToRad(45)))
+	d := float32(1.4142135)
+	want := AABB{Min: mgl32.Vec3{-d, -1, -d}, Max: mgl32.Vec3{d, 1, d}}
+	if !turned.Min.ApproxEqualThreshold(want.Min, 1e-5) || !turned.Max.ApproxEqualThreshold(want.Max, 1e-5) {
+		t.Errorf("got %v, want %v", turned, want)
+	}
+}
+
+func TestCullerCountsDraws(t *testing.T) {
+	b := useRecordingBackend(t)
+	shader, _ := newTestShader(t, testVertSource, testFragSource)
+	//the cube has nothing to feed aMystery so its draws are skipped
+	unbindable, _ := newTestShader(t, strings.Replace(testVertSource, "in vec2 aTexCoord;", "in vec2 aTexCoord;\nin vec3 aMystery;", 1), testFragSource)
+	cube := Cube(1)
+	defer cube.Delete()
+
+	c := NewCuller()
+	c.Update(testOrtho, mgl32.Ident4())
+	UseCuller(c)
+	t.Cleanup(func() { UseCuller(nil) })
+
+	shader.Use()
+	cube.Draw(shader, mgl32.Translate3D(0, 0, -5))
+	cube.Draw(shader, mgl32.Translate3D(5, 0, -5))
+	unbindable.Use()
+	cube.Draw(unbindable, mgl32.Translate3D(0, 0, -5))
+	cube.Draw(unbindable, mgl32.Translate3D(5, 0, -5))
+
+	if len(b.Draws) != 1 {
+		t.Errorf("made %d draws, want 1", len(b.Draws))
+	}
+	if c.Drawn != 1 || c.Culled != 2 {
+		t.Errorf("counted %d drawn and %d culled, want 1 and 2", c.Drawn, c.Culled)
+	}
+
+	c.Update(testOrtho, mgl32.Ident4())
+	if c.Drawn != 0 || c.Culled != 0 {
+		t.Errorf("Update left %d drawn and %d culled", c.Drawn, c.Culled)
+	}
+}
@@ -0,0 +1,191 @@
+package helpers
+
+import (
+	"fmt"
+	"time"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+/*
+Buffers that keep changing after they are made
+e.g. debug lines, particles and CPU animated meshes
+*/
+
+type DynamicBuffer struct {
+	id     BufferID
+	target uint32
+	usage  uint32
+	size   int //bytes of storage
+}
+
+// makes a buffer with size bytes of uninitialised storage
+// usage is normally gl.DYNAMIC_DRAW or gl.STREAM_DRAW
+func NewDynamicBuffer(target uint32, size int, usage uint32) *DynamicBuffer {
+	b := DynamicBuffer{
+		id:     GenBindBuffer(target),
+		target: target,
+		usage:  usage,
+		size:   size,
+	}
+	backend.BufferData(target, size, nil, usage)
+	return &b
+}
+
+func (b *DynamicBuffer) ID() BufferID {
+	return b.id
+}
+
+func (b *DynamicBuffer) Size() int {
+	return b.size
+}
+
+func (b *DynamicBuffer) Bind() {
+	backend.BindBuffer(b.target, uint32(b.id))
+}
+
+func (b *DynamicBuffer) Delete() {
+	DeleteBuffer(b.id)
+	b.id = 0
+}
+
+// overwrites part of the buffer starting offset bytes in
+// panics if the data doesn't fit, OrphanBuffer can grow it
+func UpdateBuffer[T any](b *DynamicBuffer, offset int, data []T) {
+	size := byteSize(data)
+	if offset < 0 || offset+size > b.size {
+		panic(fmt.Errorf("writing %d bytes at %d overflows a %d byte buffer", size, offset, b.size))
+	}
+	if size == 0 {
+		return
+	}
+	b.Bind()
+	backend.BufferSubData(b.target, offset, size, gl.Ptr(data))
+}
+
+// replaces the whole buffer with data, the old storage is handed back to the
+// driver so this doesn't wait for draws that are still reading it
+// the buffer grows to fit data but never shrinks
+func OrphanBuffer[T any](b *DynamicBuffer, data []T) {
+	size := byteSize(data)
+	b.size = max(b.size, size)
+
+	b.Bind()
+	backend.BufferData(b.target, b.size, nil, b.usage)
+	if size > 0 {
+		backend.BufferSubData(b.target, 0, size, gl.Ptr(data))
+	}
+}
+
+func byteSize[T any](data []T) int {
+	var v T
+	return len(data) * int(unsafe.Sizeof(v))
+}
+
+// the number of frames the GPU can be behind the CPU
+const ringRegions = 3
+
+// a buffer split into one region per frame in flight, each frame writes to
+// the next region so the CPU never overwrites data the GPU is still drawing
+//
+//	ring.BeginFrame()
+//	offset := helpers.RingWrite(ring, lines)
+//	gl.DrawArrays(gl.LINES, int32(offset/stride), int32(len(lines)))
+//	ring.EndFrame()
+type RingBuffer struct {
+	buffer     *DynamicBuffer
+	regionSize int
+	region     int                  //the region being written this frame
+	used       int                  //bytes written to it so far
+	fences     [ringRegions]uintptr //set when the GPU has been given the draws reading each region
+}
+
+// makes a ring with regionSize bytes for each frame
+func NewRingBuffer(target uint32, regionSize int) *RingBuffer {
+	r := RingBuffer{
+		buffer:     NewDynamicBuffer(target, regionSize*ringRegions, gl.STREAM_DRAW),
+		regionSize: regionSize,
+		region:     ringRegions - 1,
+	}
+	return &r
+}
+
+func (r *RingBuffer) ID() BufferID {
+	return r.buffer.ID()
+}
+
+func (r *RingBuffer) Bind() {
+	r.buffer.Bind()
+}
+
+// moves on to the next region, waiting for the GPU if it still hasn't finished
+// the draws that read it three frames ago. call it before the frame's writes
+func (r *RingBuffer) BeginFrame() {
+	r.region = (r.region + 1) % ringRegions
+	r.used = 0
+
+	fence := r.fences[r.region]
+	if fence == 0 {
+		return
+	}
+	for {
+		result := backend.ClientWaitSync(fence, gl.SYNC_FLUSH_COMMANDS_BIT, uint64(time.Second))
+		if result == gl.WAIT_FAILED {
+			panic(fmt.Errorf("waiting for ring buffer region %d failed", r.region))
+		}
+		if result != gl.TIMEOUT_EXPIRED {
+			break
+		}
+	}
+	backend.DeleteSync(fence)
+	r.fences[r.region] = 0
+}
+
+// call after the draws that read this frame's writes
+func (r *RingBuffer) EndFrame() {
+	r.fences[r.region] = backend.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0)
+}
+
+func (r *RingBuffer) Delete() {
+	for i, fence := range r.fences {
+		if fence != 0 {
+			backend.DeleteSync(fence)
+			r.fences[i] = 0
+		}
+	}
+	r.buffer.Delete()
+}
+
+// copies data into this frame's region and returns its byte offset in the buffer
+// the offset is a multiple of T's size so offset/stride is the first vertex
+// panics if the region is full
+func RingWrite[T any](r *RingBuffer, data []T) int {
+	var v T
+	elementSize := int(unsafe.Sizeof(v))
+	start := r.region * r.regionSize
+	offset := start + r.used
+	if rem := offset % elementSize; rem != 0 {
+		offset += elementSize - rem
+	}
+
+	size := byteSize(data)
+	if offset+size > start+r.regionSize {
+		panic(fmt.Errorf("ring buffer region of %d bytes is full, %d bytes don't fit", r.regionSize, size))
+	}
+	r.used = offset + size - start
+	if size == 0 {
+		return offset
+	}
+
+	//BeginFrame already waited for the GPU so there's no need for the driver to sync
+	r.buffer.Bind()
+	ptr := backend.MapBufferRange(r.buffer.target, offset, size, gl.MAP_WRITE_BIT|gl.MAP_INVALIDATE_RANGE_BIT|gl.MAP_UNSYNCHRONIZED_BIT)
+	if ptr == nil {
+		panic(fmt.Errorf("failed to map %d bytes of the ring buffer", size))
+	}
+	copy(unsafe.Slice((*byte)(ptr), size), unsafe.Slice((*byte)(unsafe.Pointer(&data[0])), size))
+	backend.UnmapBuffer(r.buffer.target)
+
+	return offset
+}
@@ -0,0 +1,97 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+/*
+A flat magenta program that shaders which fail to build draw with
+instead, so it's obvious which objects are using a broken shader.
+One is shared between every broken Shader and freed when the last
+one is fixed or deleted.
+*/
+
+// the model matrix comes from the uniform or the instance data
+const errorVertSource = `#version 330 core
+in vec3 aPos;
+%s
+
+layout (std140) uniform Camera {
+	mat4 proj;
+	mat4 view;
+	vec3 viewPos;
+};
+
+void main() {
+	gl_Position = proj*view*%s*vec4(aPos,1.0);
+}
+`
+
+const errorFragSource = `#version 330 core
+out vec4 FragColor;
+
+void main() {
+	FragColor = vec4(1.0, 0.0, 1.0, 1.0);
+}
+`
+
+var showErrorShader bool
+
+// when on a shader that fails to build draws in magenta until it's fixed
+// instead of keeping its last working program, off by default
+func ShowShaderErrors(show bool) {
+	showErrorShader = show
+}
+
+type errorProgram struct {
+	id    ProgramID
+	users int
+}
+
+var errorPrograms = make(map[bool]*errorProgram) //instanced or not -> program
+
+func acquireErrorProgram(instanced bool) ProgramID {
+	p, ok := errorPrograms[instanced]
+	if !ok {
+		source := fmt.Sprintf(errorVertSource, "uniform mat4 model;", "model")
+		if instanced {
+			source = fmt.Sprintf(errorVertSource, "in mat4 aInstanceModel;", "aInstanceModel")
+		}
+		vert := CreateShader(source, gl.VERTEX_SHADER)
+		frag := CreateShader(errorFragSource, gl.FRAGMENT_SHADER)
+		id, err := linkProgram("error shader", vert, frag)
+		if err != nil {
+			panic(err)
+		}
+
+		p = &errorProgram{id: id}
+		errorPrograms[instanced] = p
+	}
+	p.users++
+	return p.id
+}
+
+func releaseErrorProgram(id ProgramID) {
+	for instanced, p := range errorPrograms {
+		if p.id != id {
+			continue
+		}
+		p.users--
+		if p.users == 0 {
+			DeleteProgram(p.id)
+			delete(errorPrograms, instanced)
+		}
+		return
+	}
+}
+
+func readFileOrEmpty(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
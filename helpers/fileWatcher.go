@@ -0,0 +1,180 @@
+package helpers
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+/*
+Polls files on its own goroutine so nothing on the GL thread has to stat them.
+Each watched key e.g. a *Shader has a set of files and is posted once
+the files have stopped changing for the debounce time, editors often
+write a file several times or delete and rename it when saving.
+A file that is missing is assumed to be part way through a save and is
+checked again on the next poll.
+*/
+
+type FileWatcher[K comparable] struct {
+	interval time.Duration
+	debounce time.Duration
+	changes  chan K
+	stop     chan struct{}
+	stopped  chan struct{} //closed once run has returned
+
+	mu      sync.Mutex
+	keys    map[K][]string
+	files   map[string]*watchedFile[K]
+	pending map[K]bool //posted but not taken by Next yet
+}
+
+type watchedFile[K comparable] struct {
+	modTime  time.Time
+	size     int64
+	missing  bool
+	changed  bool      //waiting for the file to settle
+	settleAt time.Time //when it will have been unchanged for the debounce time
+	keys     map[K]bool
+}
+
+// starts a goroutine that checks the files every interval until Stop
+func NewFileWatcher[K comparable](interval, debounce time.Duration) *FileWatcher[K] {
+	w := FileWatcher[K]{
+		interval: interval,
+		debounce: debounce,
+		changes:  make(chan K, 64),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+		keys:     make(map[K][]string),
+		files:    make(map[string]*watchedFile[K]),
+		pending:  make(map[K]bool),
+	}
+	go w.run()
+	return &w
+}
+
+func (w *FileWatcher[K]) run() {
+	defer close(w.stopped)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case now := <-ticker.C:
+			w.poll(now)
+		}
+	}
+}
+
+// waits for the goroutine to finish so no poll runs after it returns
+func (w *FileWatcher[K]) Stop() {
+	close(w.stop)
+	<-w.stopped
+}
+
+// sets the files that key is posted for, replacing any it had before
+// their current state is the baseline changes are compared against
+func (w *FileWatcher[K]) Watch(key K, paths []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.unwatch(key)
+	w.keys[key] = paths
+	for _, path := range paths {
+		f, ok := w.files[path]
+		if !ok {
+			f = &watchedFile[K]{keys: make(map[K]bool)}
+			f.update(path)
+			w.files[path] = f
+		}
+		f.keys[key] = true
+	}
+}
+
+func (w *FileWatcher[K]) Unwatch(key K) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.unwatch(key)
+}
+
+func (w *FileWatcher[K]) unwatch(key K) {
+	for _, path := range w.keys[key] {
+		f := w.files[path]
+		delete(f.keys, key)
+		if len(f.keys) == 0 {
+			delete(w.files, path)
+		}
+	}
+	delete(w.keys, key)
+	delete(w.pending, key)
+}
+
+// the number of keys being watched
+func (w *FileWatcher[K]) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.keys)
+}
+
+// takes the next key whose files changed without blocking
+// keys unwatched since they were posted are skipped
+func (w *FileWatcher[K]) Next() (K, bool) {
+	for {
+		select {
+		case key := <-w.changes:
+			w.mu.Lock()
+			_, watched := w.keys[key]
+			delete(w.pending, key)
+			w.mu.Unlock()
+			if watched {
+				return key, true
+			}
+		default:
+			var none K
+			return none, false
+		}
+	}
+}
+
+func (w *FileWatcher[K]) poll(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for path, f := range w.files {
+		if f.update(path) {
+			f.changed = true
+			f.settleAt = now.Add(w.debounce)
+			continue
+		}
+		if !f.changed || f.missing || now.Before(f.settleAt) {
+			continue
+		}
+
+		f.changed = false
+		for key := range f.keys {
+			if w.pending[key] {
+				continue
+			}
+			select {
+			case w.changes <- key:
+				w.pending[key] = true
+			default:
+				f.changed = true //full, try again next poll
+			}
+		}
+	}
+}
+
+// stats the file and reports if it's different to last time
+func (f *watchedFile[K]) update(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		f.missing = true
+		return false
+	}
+
+	changed := f.missing || !info.ModTime().Equal(f.modTime) || info.Size() != f.size
+	f.modTime, f.size, f.missing = info.ModTime(), info.Size(), false
+	return changed
+}
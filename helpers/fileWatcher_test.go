@@ -0,0 +1,104 @@
+package helpers
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// a watcher whose goroutine never ticks so the test drives poll itself
+func newPolledWatcher(t *testing.T, debounce time.Duration) *FileWatcher[string] {
+	w := NewFileWatcher[string](time.Hour, debounce)
+	t.Cleanup(w.Stop)
+	return w
+}
+
+func TestFileWatcherDebounce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.frag")
+	writeFile(t, path, "")
+	w := newPolledWatcher(t, 100*time.Millisecond)
+	w.Watch("shader", []string{path})
+
+	//the sizes differ so the writes are seen even if the clock is coarse
+	start := time.Now()
+	writeFile(t, path, "a")
+	w.poll(start)
+	writeFile(t, path, "bb")
+	w.poll(start.Add(50 * time.Millisecond))
+
+	w.poll(start.Add(120 * time.Millisecond))
+	if key, ok := w.Next(); ok {
+		t.Fatalf("%s was posted before the second write settled", key)
+	}
+
+	w.poll(start.Add(160 * time.Millisecond))
+	if key, ok := w.Next(); !ok || key != "shader" {
+		t.Fatalf("got %q, %v after the writes settled, want shader", key, ok)
+	}
+	w.poll(start.Add(300 * time.Millisecond))
+	if key, ok := w.Next(); ok {
+		t.Errorf("%s was posted twice for one change", key)
+	}
+}
+
+func TestFileWatcherSharedFile(t *testing.T) {
+	dir := t.TempDir()
+	common, own := filepath.Join(dir, "common.glsl"), filepath.Join(dir, "own.frag")
+	writeFile(t, common, "")
+	writeFile(t, own, "")
+	w := newPolledWatcher(t, 0)
+	w.Watch("first", []string{common, own})
+	w.Watch("second", []string{common})
+
+	start := time.Now()
+	writeFile(t, common, "changed")
+	writeFile(t, own, "changed")
+	w.poll(start)
+	w.poll(start.Add(time.Millisecond))
+
+	//first is posted once even though both of its files changed
+	got := make(map[string]int)
+	for key, ok := w.Next(); ok; key, ok = w.Next() {
+		got[key]++
+	}
+	if got["first"] != 1 || got["second"] != 1 || len(got) != 2 {
+		t.Errorf("got %v, want first and second once each", got)
+	}
+
+	//keys unwatched after they were posted aren't given out
+	writeFile(t, common, "again")
+	w.poll(start.Add(2 * time.Millisecond))
+	w.poll(start.Add(3 * time.Millisecond))
+	w.Unwatch("second")
+	if key, ok := w.Next(); !ok || key != "first" {
+		t.Errorf("got %q, %v, want first", key, ok)
+	}
+	if key, ok := w.Next(); ok {
+		t.Errorf("got unwatched %s", key)
+	}
+}
+
+func TestFileWatcherStop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.frag")
+	writeFile(t, path, "")
+	w := NewFileWatcher[string](time.Millisecond, time.Millisecond)
+	w.Watch("shader", []string{path})
+
+	//change the watched files while the goroutine is polling
+	for i := 0; i < 20; i++ {
+		writeFile(t, path, string(make([]byte, i)))
+		w.Watch("other", []string{path})
+		w.Unwatch("other")
+		time.Sleep(time.Millisecond)
+	}
+
+	w.Stop()
+	for _, ok := w.Next(); ok; _, ok = w.Next() {
+		//drop anything posted before Stop
+	}
+	writeFile(t, path, "after stop")
+	time.Sleep(20 * time.Millisecond)
+	if key, ok := w.Next(); ok {
+		t.Errorf("%s was posted after Stop", key)
+	}
+}
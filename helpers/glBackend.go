@@ -0,0 +1,312 @@
+package helpers
+
+import (
+	"strings"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// every openGL call the helpers make goes through a Backend
+// so they can run against RecordingBackend without a GPU
+// the constants are still the ones from go-gl
+type Backend interface {
+	GenBuffer() uint32
+	DeleteBuffer(buffer uint32)
+	BindBuffer(target, buffer uint32)
+	BindBufferBase(target, index, buffer uint32)
+	BufferData(target uint32, size int, data unsafe.Pointer, usage uint32)
+	BufferSubData(target uint32, offset, size int, data unsafe.Pointer)
+	MapBufferRange(target uint32, offset, length int, access uint32) unsafe.Pointer
+	UnmapBuffer(target uint32) bool
+
+	GenVertexArray() uint32
+	DeleteVertexArray(vao uint32)
+	BindVertexArray(vao uint32)
+	VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset uintptr)
+	VertexAttribIPointer(index uint32, size int32, xtype uint32, stride int32, offset uintptr)
+	EnableVertexAttribArray(index uint32)
+	VertexAttribDivisor(index, divisor uint32)
+
+	DrawArrays(mode uint32, first, count int32)
+	DrawElements(mode uint32, count int32, xtype uint32, offset uintptr)
+	DrawArraysInstanced(mode uint32, first, count, instances int32)
+	DrawElementsInstanced(mode uint32, count int32, xtype uint32, offset uintptr, instances int32)
+
+	GenTexture() uint32
+	DeleteTexture(texture uint32)
+	ActiveTexture(unit uint32)
+	BindTexture(target, texture uint32)
+	TexImage2D(target uint32, level, internalFormat, width, height int32, format, xtype uint32, pixels unsafe.Pointer)
+	TexParameteri(target, pname uint32, param int32)
+	GenerateMipmap(target uint32)
+
+	CreateShader(stage uint32) uint32
+	ShaderSource(shader uint32, source string)
+	CompileShader(shader uint32)
+	GetShaderiv(shader, pname uint32) int32
+	GetShaderInfoLog(shader uint32, bufSize int32) string
+	DeleteShader(shader uint32)
+
+	CreateProgram() uint32
+	AttachShader(program, shader uint32)
+	LinkProgram(program uint32)
+	GetProgramiv(program, pname uint32) int32
+	GetProgramInfoLog(program uint32, bufSize int32) string
+	UseProgram(program uint32)
+	DeleteProgram(program uint32)
+
+	GetActiveAttrib(program, index uint32) (name string, size int32, xtype uint32)
+	GetAttribLocation(program uint32, name string) int32
+	GetActiveUniform(program, index uint32) (name string, size int32, xtype uint32)
+	GetUniformLocation(program uint32, name string) int32
+	GetUniformBlockIndex(program uint32, name string) uint32
+	GetActiveUniformBlockiv(program, block, pname uint32) int32
+	UniformBlockBinding(program, block, binding uint32)
+
+	Uniform1f(location int32, v float32)
+	Uniform1i(location int32, v int32)
+	Uniform1fv(location int32, count int32, v *float32)
+	Uniform1iv(location int32, count int32, v *int32)
+	Uniform2fv(location int32, count int32, v *float32)
+	Uniform3fv(location int32, count int32, v *float32)
+	Uniform4fv(location int32, count int32, v *float32)
+	UniformMatrix3fv(location int32, count int32, transpose bool, v *float32)
+	UniformMatrix4fv(location int32, count int32, transpose bool, v *float32)
+
+	FenceSync(condition, flags uint32) uintptr
+	ClientWaitSync(sync uintptr, flags uint32, timeout uint64) uint32
+	DeleteSync(sync uintptr)
+
+	Enable(capability uint32)
+	GetString(name uint32) string
+}
+
+var backend Backend = GoGLBackend{}
+
+// switches every helper over to b e.g. a RecordingBackend in tests
+// objects made with the old backend can't be used with the new one
+func UseBackend(b Backend) {
+	backend = b
+}
+
+// the real backend, calls straight through to go-gl
+type GoGLBackend struct{}
+
+func (GoGLBackend) GenBuffer() uint32 {
+	var buffer uint32
+	gl.GenBuffers(1, &buffer)
+	return buffer
+}
+func (GoGLBackend) DeleteBuffer(buffer uint32) {
+	gl.DeleteBuffers(1, &buffer)
+}
+func (GoGLBackend) BindBuffer(target, buffer uint32) {
+	gl.BindBuffer(target, buffer)
+}
+func (GoGLBackend) BindBufferBase(target, index, buffer uint32) {
+	gl.BindBufferBase(target, index, buffer)
+}
+func (GoGLBackend) BufferData(target uint32, size int, data unsafe.Pointer, usage uint32) {
+	gl.BufferData(target, size, data, usage)
+}
+func (GoGLBackend) BufferSubData(target uint32, offset, size int, data unsafe.Pointer) {
+	gl.BufferSubData(target, offset, size, data)
+}
+func (GoGLBackend) MapBufferRange(target uint32, offset, length int, access uint32) unsafe.Pointer {
+	return gl.MapBufferRange(target, offset, length, access)
+}
+func (GoGLBackend) UnmapBuffer(target uint32) bool {
+	return gl.UnmapBuffer(target)
+}
+
+func (GoGLBackend) GenVertexArray() uint32 {
+	var vao uint32
+	gl.GenVertexArrays(1, &vao)
+	return vao
+}
+func (GoGLBackend) DeleteVertexArray(vao uint32) {
+	gl.DeleteVertexArrays(1, &vao)
+}
+func (GoGLBackend) BindVertexArray(vao uint32) {
+	gl.BindVertexArray(vao)
+}
+func (GoGLBackend) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset uintptr) {
+	gl.VertexAttribPointerWithOffset(index, size, xtype, normalized, stride, offset)
+}
+func (GoGLBackend) VertexAttribIPointer(index uint32, size int32, xtype uint32, stride int32, offset uintptr) {
+	gl.VertexAttribIPointerWithOffset(index, size, xtype, stride, offset)
+}
+func (GoGLBackend) EnableVertexAttribArray(index uint32) {
+	gl.EnableVertexAttribArray(index)
+}
+func (GoGLBackend) VertexAttribDivisor(index, divisor uint32) {
+	gl.VertexAttribDivisor(index, divisor)
+}
+
+func (GoGLBackend) DrawArrays(mode uint32, first, count int32) {
+	gl.DrawArrays(mode, first, count)
+}
+func (GoGLBackend) DrawElements(mode uint32, count int32, xtype uint32, offset uintptr) {
+	gl.DrawElementsWithOffset(mode, count, xtype, offset)
+}
+func (GoGLBackend) DrawArraysInstanced(mode uint32, first, count, instances int32) {
+	gl.DrawArraysInstanced(mode, first, count, instances)
+}
+func (GoGLBackend) DrawElementsInstanced(mode uint32, count int32, xtype uint32, offset uintptr, instances int32) {
+	gl.DrawElementsInstanced(mode, count, xtype, gl.PtrOffset(int(offset)), instances)
+}
+
+func (GoGLBackend) GenTexture() uint32 {
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	return texture
+}
+func (GoGLBackend) DeleteTexture(texture uint32) {
+	gl.DeleteTextures(1, &texture)
+}
+func (GoGLBackend) ActiveTexture(unit uint32) {
+	gl.ActiveTexture(unit)
+}
+func (GoGLBackend) BindTexture(target, texture uint32) {
+	gl.BindTexture(target, texture)
+}
+func (GoGLBackend) TexImage2D(target uint32, level, internalFormat, width, height int32, format, xtype uint32, pixels unsafe.Pointer) {
+	gl.TexImage2D(target, level, internalFormat, width, height, 0, format, xtype, pixels)
+}
+func (GoGLBackend) TexParameteri(target, pname uint32, param int32) {
+	gl.TexParameteri(target, pname, param)
+}
+func (GoGLBackend) GenerateMipmap(target uint32) {
+	gl.GenerateMipmap(target)
+}
+
+func (GoGLBackend) CreateShader(stage uint32) uint32 {
+	return gl.CreateShader(stage)
+}
+func (GoGLBackend) ShaderSource(shader uint32, source string) {
+	csource, free := gl.Strs(source + "\x00")
+	gl.ShaderSource(shader, 1, csource, nil)
+	free()
+}
+func (GoGLBackend) CompileShader(shader uint32) {
+	gl.CompileShader(shader)
+}
+func (GoGLBackend) GetShaderiv(shader, pname uint32) int32 {
+	var v int32
+	gl.GetShaderiv(shader, pname, &v)
+	return v
+}
+func (GoGLBackend) GetShaderInfoLog(shader uint32, bufSize int32) string {
+	log := strings.Repeat("\x00", int(bufSize+1))
+	gl.GetShaderInfoLog(shader, bufSize, nil, gl.Str(log))
+	return log
+}
+func (GoGLBackend) DeleteShader(shader uint32) {
+	gl.DeleteShader(shader)
+}
+
+func (GoGLBackend) CreateProgram() uint32 {
+	return gl.CreateProgram()
+}
+func (GoGLBackend) AttachShader(program, shader uint32) {
+	gl.AttachShader(program, shader)
+}
+func (GoGLBackend) LinkProgram(program uint32) {
+	gl.LinkProgram(program)
+}
+func (GoGLBackend) GetProgramiv(program, pname uint32) int32 {
+	var v int32
+	gl.GetProgramiv(program, pname, &v)
+	return v
+}
+func (GoGLBackend) GetProgramInfoLog(program uint32, bufSize int32) string {
+	log := strings.Repeat("\x00", int(bufSize+1))
+	gl.GetProgramInfoLog(program, bufSize, nil, gl.Str(log))
+	return log
+}
+func (GoGLBackend) UseProgram(program uint32) {
+	gl.UseProgram(program)
+}
+func (GoGLBackend) DeleteProgram(program uint32) {
+	gl.DeleteProgram(program)
+}
+
+func (GoGLBackend) GetActiveAttrib(program, index uint32) (string, int32, uint32) {
+	var maxLength, length, size int32
+	var xtype uint32
+	gl.GetProgramiv(program, gl.ACTIVE_ATTRIBUTE_MAX_LENGTH, &maxLength)
+	buf := make([]uint8, maxLength+1)
+	gl.GetActiveAttrib(program, index, int32(len(buf)), &length, &size, &xtype, &buf[0])
+	return string(buf[:length]), size, xtype
+}
+func (GoGLBackend) GetAttribLocation(program uint32, name string) int32 {
+	return gl.GetAttribLocation(program, gl.Str(name+"\x00"))
+}
+func (GoGLBackend) GetActiveUniform(program, index uint32) (string, int32, uint32) {
+	var maxLength, length, size int32
+	var xtype uint32
+	gl.GetProgramiv(program, gl.ACTIVE_UNIFORM_MAX_LENGTH, &maxLength)
+	buf := make([]uint8, maxLength+1)
+	gl.GetActiveUniform(program, index, int32(len(buf)), &length, &size, &xtype, &buf[0])
+	return string(buf[:length]), size, xtype
+}
+func (GoGLBackend) GetUniformLocation(program uint32, name string) int32 {
+	return gl.GetUniformLocation(program, gl.Str(name+"\x00"))
+}
+func (GoGLBackend) GetUniformBlockIndex(program uint32, name string) uint32 {
+	return gl.GetUniformBlockIndex(program, gl.Str(name+"\x00"))
+}
+func (GoGLBackend) GetActiveUniformBlockiv(program, block, pname uint32) int32 {
+	var v int32
+	gl.GetActiveUniformBlockiv(program, block, pname, &v)
+	return v
+}
+func (GoGLBackend) UniformBlockBinding(program, block, binding uint32) {
+	gl.UniformBlockBinding(program, block, binding)
+}
+
+func (GoGLBackend) Uniform1f(location int32, v float32) {
+	gl.Uniform1f(location, v)
+}
+func (GoGLBackend) Uniform1i(location int32, v int32) {
+	gl.Uniform1i(location, v)
+}
+func (GoGLBackend) Uniform1fv(location int32, count int32, v *float32) {
+	gl.Uniform1fv(location, count, v)
+}
+func (GoGLBackend) Uniform1iv(location int32, count int32, v *int32) {
+	gl.Uniform1iv(location, count, v)
+}
+func (GoGLBackend) Uniform2fv(location int32, count int32, v *float32) {
+	gl.Uniform2fv(location, count, v)
+}
+func (GoGLBackend) Uniform3fv(location int32, count int32, v *float32) {
+	gl.Uniform3fv(location, count, v)
+}
+func (GoGLBackend) Uniform4fv(location int32, count int32, v *float32) {
+	gl.Uniform4fv(location, count, v)
+}
+func (GoGLBackend) UniformMatrix3fv(location int32, count int32, transpose bool, v *float32) {
+	gl.UniformMatrix3fv(location, count, transpose, v)
+}
+func (GoGLBackend) UniformMatrix4fv(location int32, count int32, transpose bool, v *float32) {
+	gl.UniformMatrix4fv(location, count, transpose, v)
+}
+
+func (GoGLBackend) FenceSync(condition, flags uint32) uintptr {
+	return gl.FenceSync(condition, flags)
+}
+func (GoGLBackend) ClientWaitSync(sync uintptr, flags uint32, timeout uint64) uint32 {
+	return gl.ClientWaitSync(sync, flags, timeout)
+}
+func (GoGLBackend) DeleteSync(sync uintptr) {
+	gl.DeleteSync(sync)
+}
+
+func (GoGLBackend) Enable(capability uint32) {
+	gl.Enable(capability)
+}
+func (GoGLBackend) GetString(name uint32) string {
+	return gl.GoStr(gl.GetString(name))
+}
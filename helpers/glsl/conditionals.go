@@ -0,0 +1,365 @@
+package glsl
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+Just enough of the GLSL preprocessor to find the declarations a shader
+would be compiled with: #ifdef, #ifndef, #if, #elif and #else are evaluated
+with the defines given. Macros aren't expanded in the code itself.
+*/
+
+// one #if ... #endif
+type condition struct {
+	at     Line
+	active bool //lines are being kept
+	taken  bool //one of the branches has been kept
+	parent bool //the #if itself is being kept
+}
+
+// the lines compiled with these defines, comments are blanked out
+// and the preprocessor directives are left out
+func (s Source) Active(defines map[string]string) ([]Line, error) {
+	defined := make(map[string]string)
+	for name, value := range defines {
+		defined[name] = value
+	}
+
+	var lines []Line
+	var conditions []condition
+	active := func() bool {
+		return len(conditions) == 0 || conditions[len(conditions)-1].active
+	}
+	inComment := make(map[int]bool) //a /* */ comment is open in the file
+
+	for _, line := range s.Lines {
+		line.Text, inComment[line.File] = stripComments(line.Text, inComment[line.File])
+		trimmed := strings.TrimSpace(line.Text)
+		if !strings.HasPrefix(trimmed, "#") {
+			if active() {
+				lines = append(lines, line)
+			}
+			continue
+		}
+
+		directive, rest := splitDirective(trimmed)
+		switch directive {
+		case "ifdef", "ifndef", "if":
+			parent := active()
+			taken := false
+			if parent {
+				switch directive {
+				case "ifdef":
+					_, taken = defined[rest]
+				case "ifndef":
+					_, ok := defined[rest]
+					taken = !ok
+				default:
+					var err error
+					if taken, err = evaluate(rest, defined); err != nil {
+						return lines, s.errorAt(line, "%v", err)
+					}
+				}
+			}
+			conditions = append(conditions, condition{at: line, active: parent && taken, taken: taken, parent: parent})
+
+		case "elif", "else", "endif":
+			if len(conditions) == 0 {
+				return lines, s.errorAt(line, "#%s without #if", directive)
+			}
+			c := &conditions[len(conditions)-1]
+			switch directive {
+			case "elif":
+				c.active = false
+				if c.parent && !c.taken {
+					var err error
+					if c.active, err = evaluate(rest, defined); err != nil {
+						return lines, s.errorAt(line, "%v", err)
+					}
+					c.taken = c.active
+				}
+			case "else":
+				c.active = c.parent && !c.taken
+				c.taken = true
+			default:
+				conditions = conditions[:len(conditions)-1]
+			}
+
+		default:
+			if !active() {
+				continue
+			}
+			switch directive {
+			case "version":
+				defineVersion(rest, defined)
+			case "define":
+				name, value := splitDirective("#" + rest)
+				if i := strings.Index(name, "("); i >= 0 {
+					name = name[:i] //function like macros only count as defined
+				}
+				defined[name] = value
+			case "undef":
+				delete(defined, rest)
+			case "include":
+				return lines, s.errorAt(line, "can't include %s, it has to be #include \"file\"", rest)
+			case "error":
+				return lines, s.errorAt(line, "#error %s", rest)
+			}
+			//#extension, #pragma and #line don't change the declarations
+		}
+	}
+
+	if len(conditions) > 0 {
+		c := conditions[len(conditions)-1]
+		directive, _ := splitDirective(strings.TrimSpace(c.at.Text))
+		return lines, s.errorAt(c.at, "#%s without #endif", directive)
+	}
+	return lines, nil
+}
+
+// the macros the driver defines from e.g. #version 330 core
+func defineVersion(version string, defined map[string]string) {
+	number, profile := splitDirective("#" + version)
+	defined["__VERSION__"] = number
+	if n, err := strconv.Atoi(number); err == nil && n >= 150 && profile != "compatibility" {
+		defined["GL_core_profile"] = "1"
+	}
+}
+
+// e.g. #ifdef FOG -> ifdef, FOG
+func splitDirective(line string) (string, string) {
+	body := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+	if i := strings.IndexAny(body, " \t"); i >= 0 {
+		return body[:i], strings.TrimSpace(body[i:])
+	}
+	return body, ""
+}
+
+// blanks out comments keeping the columns of everything else
+// inComment says a /* */ comment is still open from an earlier line
+func stripComments(text string, inComment bool) (string, bool) {
+	out := []byte(text)
+	for i := 0; i < len(out); i++ {
+		switch {
+		case inComment:
+			if out[i] == '*' && i+1 < len(out) && out[i+1] == '/' {
+				out[i+1] = ' '
+				inComment = false
+			}
+			out[i] = ' '
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out); i++ {
+				out[i] = ' '
+			}
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '*':
+			out[i], out[i+1] = ' ', ' '
+			i++
+			inComment = true
+		}
+	}
+	return string(out), inComment
+}
+
+var featurePattern = regexp.MustCompile(`^\s*#\s*(ifdef|ifndef|if|elif|define)\b(.*)$`)
+var definedPattern = regexp.MustCompile(`\bdefined\s*\(?\s*([A-Za-z_]\w*)`)
+
+// the names the source tests with #ifdef or defined()
+// that it doesn't #define itself, so not include guards
+func (s Source) Features() []string {
+	tested := make(map[string]bool)
+	defined := make(map[string]bool)
+	inComment := make(map[int]bool)
+
+	for _, line := range s.Lines {
+		var text string
+		text, inComment[line.File] = stripComments(line.Text, inComment[line.File])
+		m := featurePattern.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		rest := strings.TrimSpace(m[2])
+		switch m[1] {
+		case "ifdef", "ifndef":
+			tested[rest] = true
+		case "if", "elif":
+			for _, d := range definedPattern.FindAllStringSubmatch(rest, -1) {
+				tested[d[1]] = true
+			}
+		case "define":
+			name, _ := splitDirective("#" + rest)
+			defined[strings.SplitN(name, "(", 2)[0]] = true
+		}
+	}
+
+	var features []string
+	for name := range tested {
+		if !defined[name] {
+			features = append(features, name)
+		}
+	}
+	sort.Strings(features)
+	return features
+}
+
+var expressionToken = regexp.MustCompile(`^\s*(\|\||&&|==|!=|<=|>=|[!()<>]|[A-Za-z_]\w*|\d\w*)`)
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+
+// the value of an #if or #elif expression, undefined names are 0
+func evaluate(expression string, defines map[string]string) (bool, error) {
+	e := expressionParser{defines: defines}
+	if err := e.tokenize(expression); err != nil {
+		return false, err
+	}
+	value, err := e.or()
+	if err == nil && e.i < len(e.tokens) {
+		err = fmt.Errorf("unexpected %q", e.tokens[e.i])
+	}
+	if err != nil {
+		return false, fmt.Errorf("can't evaluate #if %s: %w", expression, err)
+	}
+	return value != 0, nil
+}
+
+type expressionParser struct {
+	tokens  []string
+	i       int
+	defines map[string]string
+	depth   int //of macros being expanded
+}
+
+func (e *expressionParser) tokenize(expression string) error {
+	for rest := expression; strings.TrimSpace(rest) != ""; {
+		m := expressionToken.FindStringSubmatch(rest)
+		if m == nil {
+			return fmt.Errorf("can't evaluate #if %s", expression)
+		}
+		e.tokens = append(e.tokens, m[1])
+		rest = rest[len(m[0]):]
+	}
+	return nil
+}
+
+func (e *expressionParser) peek() string {
+	if e.i < len(e.tokens) {
+		return e.tokens[e.i]
+	}
+	return ""
+}
+
+func (e *expressionParser) next() string {
+	t := e.peek()
+	e.i++
+	return t
+}
+
+// each level of precedence calls the one above it
+func (e *expressionParser) binary(operators []string, operand func() (int64, error), apply func(string, int64, int64) int64) (int64, error) {
+	left, err := operand()
+	for err == nil && contains(operators, e.peek()) {
+		op := e.next()
+		var right int64
+		if right, err = operand(); err == nil {
+			left = apply(op, left, right)
+		}
+	}
+	return left, err
+}
+
+func (e *expressionParser) or() (int64, error) {
+	return e.binary([]string{"||"}, e.and, func(_ string, l, r int64) int64 { return truth(l != 0 || r != 0) })
+}
+
+func (e *expressionParser) and() (int64, error) {
+	return e.binary([]string{"&&"}, e.comparison, func(_ string, l, r int64) int64 { return truth(l != 0 && r != 0) })
+}
+
+func (e *expressionParser) comparison() (int64, error) {
+	return e.binary([]string{"==", "!=", "<", ">", "<=", ">="}, e.unary, func(op string, l, r int64) int64 {
+		switch op {
+		case "==":
+			return truth(l == r)
+		case "!=":
+			return truth(l != r)
+		case "<":
+			return truth(l < r)
+		case ">":
+			return truth(l > r)
+		case "<=":
+			return truth(l <= r)
+		}
+		return truth(l >= r)
+	})
+}
+
+func (e *expressionParser) unary() (int64, error) {
+	t := e.next()
+	switch {
+	case t == "!":
+		v, err := e.unary()
+		return truth(v == 0), err
+	case t == "(":
+		v, err := e.or()
+		if err == nil && e.next() != ")" {
+			err = fmt.Errorf("missing )")
+		}
+		return v, err
+	case t == "defined":
+		name := e.next()
+		if name == "(" {
+			name = e.next()
+			if e.next() != ")" {
+				return 0, fmt.Errorf("missing )")
+			}
+		}
+		_, ok := e.defines[name]
+		return truth(ok), nil
+	case t != "" && t[0] >= '0' && t[0] <= '9':
+		v, err := strconv.ParseInt(strings.TrimRight(t, "uU"), 0, 64)
+		if err != nil {
+			return 0, fmt.Errorf("bad number %s", t)
+		}
+		return v, nil
+	case identifierPattern.MatchString(t):
+		value, ok := e.defines[t]
+		if !ok {
+			return 0, nil
+		}
+		if e.depth > 16 {
+			return 0, fmt.Errorf("%s expands forever", t)
+		}
+		macro := expressionParser{defines: e.defines, depth: e.depth + 1}
+		if err := macro.tokenize(value); err != nil || len(macro.tokens) == 0 {
+			return 0, fmt.Errorf("%s isn't a number", t)
+		}
+		v, err := macro.or()
+		if err == nil && macro.i < len(macro.tokens) {
+			err = fmt.Errorf("%s isn't a number", t)
+		}
+		return v, err
+	}
+	if t == "" {
+		return 0, fmt.Errorf("unexpected end")
+	}
+	return 0, fmt.Errorf("unexpected %q", t)
+}
+
+func truth(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package glsl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestActive(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		defines map[string]string
+		want    []string //the lines kept, trimmed
+		err     string
+	}{
+		{
+			name: "ifdef",
+			code: "#ifdef FOG\nfog\n#else\nclear\n#endif\nafter",
+			want: []string{"clear", "after"},
+		},
+		{
+			name:    "ifdef defined",
+			code:    "#ifdef FOG\nfog\n#else\nclear\n#endif",
+			defines: map[string]string{"FOG": ""},
+			want:    []string{"fog"},
+		},
+		{
+			name: "ifndef and define",
+			code: "#ifndef GUARD\n#define GUARD\nonce\n#endif\n#ifndef GUARD\ntwice\n#endif",
+			want: []string{"once"},
+		},
+		{
+			name: "undef",
+			code: "#define A\n#undef A\n#ifdef A\na\n#endif",
+			want: nil,
+		},
+		{
+			name:    "if expression",
+			code:    "#if LIGHTS > 2 && !defined(FOG)\nmany\n#elif LIGHTS == 2\ntwo\n#else\nfew\n#endif",
+			defines: map[string]string{"LIGHTS": "2"},
+			want:    []string{"two"},
+		},
+		{
+			name:    "if macro",
+			code:    "#define MAX LIGHTS\n#if defined MAX && (MAX >= 4u)\nmany\n#endif",
+			defines: map[string]string{"LIGHTS": "0x4"},
+			want:    []string{"many"},
+		},
+		{
+			name: "macro that isn't a number",
+			code: "#define MAX (LIGHTS + 0)\n#if MAX\n#endif",
+			err:  "a:2: can't evaluate #if MAX: MAX isn't a number",
+		},
+		{
+			name: "version macros",
+			code: "#version 330 core\n#if __VERSION__ >= 330 && defined(GL_core_profile)\nnew\n#else\nold\n#endif",
+			want: []string{"new"},
+		},
+		{
+			name: "only the first branch taken",
+			code: "#if 1\none\n#elif 1\ntwo\n#else\nthree\n#endif",
+			want: []string{"one"},
+		},
+		{
+			name: "nested in an inactive branch",
+			code: "#ifdef A\n#ifndef B\nb\n#else\nnot b\n#endif\n#endif",
+			want: nil,
+		},
+		{
+			name: "comments",
+			code: "a // #ifdef A\n/* #ifdef B\n#endif */ b\n#ifdef C /* c */\nc\n#endif",
+			want: []string{"a", "", "b"}, //the comment leaves an empty line
+		},
+		{
+			name: "other directives",
+			code: "#version 330 core\n#extension GL_ARB_shading_language_420pack : enable\n#line 10 1\n#pragma optimize(off)\ncode",
+			want: []string{"code"},
+		},
+		{
+			name: "error",
+			code: "#ifndef FOG\n#error FOG has to be defined\n#endif",
+			err:  "a:2: #error FOG has to be defined",
+		},
+		{
+			name: "inactive error",
+			code: "#ifdef FOG\n#error no fog\n#endif",
+		},
+		{
+			name: "unclosed",
+			code: "#ifdef A\n#if 1\n#endif",
+			err:  "a:1: #ifdef without #endif",
+		},
+		{
+			name: "endif without if",
+			code: "a\n#endif",
+			err:  "a:2: #endif without #if",
+		},
+		{
+			name: "bad expression",
+			code: "#if 1 +\n#endif",
+			err:  "a:1: can't evaluate #if 1 +",
+		},
+		{
+			name: "bad include",
+			code: "#include <lighting.glsl>",
+			err:  "a:1: can't include <lighting.glsl>, it has to be #include \"file\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := SourceOf("a", tt.code).Active(tt.defines)
+			if got := errorText(err); got != tt.err {
+				t.Fatalf("got error %q, want %q", got, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			var got []string
+			for _, l := range lines {
+				got = append(got, strings.TrimSpace(l.Text))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActiveKeepsPlaces(t *testing.T) {
+	s, err := ReadSource("testdata/lit.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, err := s.Active(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"testdata/include/light.glsl:4: ",
+		"testdata/include/light.glsl:5: vec3 light() {",
+		"testdata/include/light.glsl:6: \treturn AMBIENT;",
+		"testdata/include/light.glsl:7: }",
+		"testdata/lit.frag:3: out vec4 FragColor;",
+		"testdata/lit.frag:4: ",
+		"testdata/lit.frag:5: void main() {",
+		"testdata/lit.frag:6: \tFragColor = vec4(light(), 1.0);",
+		"testdata/lit.frag:7: }",
+	}
+	if got := describeLines(s, lines); !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	tests := []struct {
+		code string
+		want []string
+	}{
+		{"#ifdef FOG\n#endif\n#ifndef SHADOWS\n#endif", []string{"FOG", "SHADOWS"}},
+		{"#if defined(A) || defined B\n#elif defined(C) && LIGHTS > 1\n#endif", []string{"A", "B", "C"}},
+		{"#ifndef GUARD\n#define GUARD\n#endif", nil},
+		{"#define MACRO(x) x\n#ifdef MACRO\n#endif", nil},
+		{"// #ifdef COMMENTED\n/*\n#ifdef BLOCK\n*/", nil},
+	}
+	for _, tt := range tests {
+		if got := SourceOf("a", tt.code).Features(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Features of %q got %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package glsl
+
+/*
+The vertex inputs helpers.Object can feed a shader, kept here without
+any GL so tools that check shaders can use the same tables.
+*/
+
+// the shader input names each semantic is bound to
+var SemanticInputs = map[string][]string{
+	"position":  {"aPos", "aPosition"},
+	"uv":        {"aTexCoord", "aUV"},
+	"normal":    {"aNormal"},
+	"tangent":   {"aTangent"},
+	"bitangent": {"aBitangent"},
+	"color":     {"aColor", "aColour"},
+}
+
+// the semantic an input name is for, or "" if it hasn't got one
+func SemanticOf(input string) string {
+	for semantic, names := range SemanticInputs {
+		for _, n := range names {
+			if n == input {
+				return semantic
+			}
+		}
+	}
+	return ""
+}
+
+// the type of each semantic in the verticies of a mesh Object uploads
+var MeshAttributes = map[string]string{
+	"position":  "vec3",
+	"uv":        "vec2",
+	"normal":    "vec3",
+	"tangent":   "vec4",
+	"bitangent": "vec3",
+}
+
+// the per instance inputs DrawInstanced fills and their types
+var InstanceInputs = map[string]string{
+	"aInstanceModel":   "mat4",
+	"aInstanceColor":   "vec4",
+	"aInstanceTexture": "float",
+}
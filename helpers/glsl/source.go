@@ -0,0 +1,116 @@
+package glsl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+/*
+Shader files can paste in other files with
+
+	#include "lighting.glsl"
+
+found relative to the file including it. ReadSource resolves the includes
+and keeps where every line came from, helpers.PreprocessShader turns that
+into #line directives for the driver and cmd/shaderlint evaluates the
+conditionals itself with Active.
+*/
+
+// a line of a shader and the file it came from
+type Line struct {
+	Text   string
+	File   int //an index into Source.Files
+	Number int //from 1
+}
+
+type Source struct {
+	Files []string //Files[0] is the main file then each include in the order it's pasted in
+	Lines []Line
+}
+
+// a problem at a line of a shader file
+type Error struct {
+	File    string
+	Line    int //0 for the whole file
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+}
+
+func (s Source) errorAt(l Line, format string, args ...any) *Error {
+	return &Error{s.Files[l.File], l.Number, fmt.Sprintf(format, args...)}
+}
+
+var includePattern = regexp.MustCompile(`^\s*#\s*include\s+"([^"]+)"\s*(//.*)?$`)
+
+// reads the shader at path with its includes pasted in
+// on an error Files still has every file read before it
+func ReadSource(path string) (Source, error) {
+	var s Source
+	err := s.read(filepath.Clean(path), nil)
+	return s, err
+}
+
+// a shader that isn't from a file, it has no includes
+func SourceOf(name, code string) Source {
+	s := Source{Files: []string{name}}
+	for i, text := range splitLines(code) {
+		s.Lines = append(s.Lines, Line{text, 0, i + 1})
+	}
+	return s
+}
+
+// appends the file's lines, stack is the chain of files including it
+func (s *Source) read(path string, stack []string) error {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	file := len(s.Files)
+	s.Files = append(s.Files, path)
+	stack = append(stack, path)
+
+	for i, text := range splitLines(string(code)) {
+		line := Line{text, file, i + 1}
+		match := includePattern.FindStringSubmatch(text)
+		if match == nil {
+			s.Lines = append(s.Lines, line)
+			continue
+		}
+
+		included := filepath.Join(filepath.Dir(path), match[1])
+		for j, parent := range stack {
+			if parent == included {
+				cycle := append(append([]string{}, stack[j:]...), included)
+				return s.errorAt(line, "include cycle: %s", strings.Join(cycle, " -> "))
+			}
+		}
+		if err := s.read(included, stack); err != nil {
+			if _, ok := err.(*Error); ok {
+				return err
+			}
+			return s.errorAt(line, "%v", err)
+		}
+	}
+	return nil
+}
+
+// splits like bufio.ScanLines, without a \r at the end of a line or an empty last line
+func splitLines(code string) []string {
+	if code == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(code, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(l, "\r")
+	}
+	return lines
+}
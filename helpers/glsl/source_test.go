@@ -0,0 +1,100 @@
+package glsl
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// each line as file:number: text
+func describeLines(s Source, lines []Line) []string {
+	var out []string
+	for _, l := range lines {
+		out = append(out, fmt.Sprintf("%s:%d: %s", s.Files[l.File], l.Number, l.Text))
+	}
+	return out
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func TestReadSource(t *testing.T) {
+	tests := []struct {
+		path  string
+		files []string
+		lines []string
+		err   string
+	}{
+		{
+			path:  "testdata/lit.frag",
+			files: []string{"testdata/lit.frag", "testdata/include/light.glsl", "testdata/include/common.glsl"},
+			lines: []string{
+				"testdata/lit.frag:1: #version 330 core",
+				"testdata/include/light.glsl:1: #ifndef LIGHT_GLSL",
+				"testdata/include/light.glsl:2: #define LIGHT_GLSL",
+				"testdata/include/common.glsl:1: #define AMBIENT vec3(0.1)",
+				"testdata/include/light.glsl:4: ",
+				"testdata/include/light.glsl:5: vec3 light() {",
+				"testdata/include/light.glsl:6: \treturn AMBIENT;",
+				"testdata/include/light.glsl:7: }",
+				"testdata/include/light.glsl:8: #endif",
+				"testdata/lit.frag:3: out vec4 FragColor;",
+				"testdata/lit.frag:4: ",
+				"testdata/lit.frag:5: void main() {",
+				"testdata/lit.frag:6: \tFragColor = vec4(light(), 1.0);",
+				"testdata/lit.frag:7: }",
+			},
+		},
+		{
+			path:  "testdata/cycle.frag",
+			files: []string{"testdata/cycle.frag", "testdata/include/cycleB.glsl", "testdata/include/cycleC.glsl"},
+			err:   "testdata/include/cycleC.glsl:2: include cycle: testdata/include/cycleB.glsl -> testdata/include/cycleC.glsl -> testdata/include/cycleB.glsl",
+		},
+		{
+			path:  "testdata/missing.frag",
+			files: []string{"testdata/missing.frag"},
+			err:   "testdata/missing.frag:2: open testdata/include/missing.glsl: no such file or directory",
+		},
+		{
+			path: "testdata/nothing.frag",
+			err:  "open testdata/nothing.frag: no such file or directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			s, err := ReadSource("./" + tt.path)
+			if got := errorText(err); got != tt.err {
+				t.Errorf("got error %q, want %q", got, tt.err)
+			}
+			if !reflect.DeepEqual(s.Files, tt.files) {
+				t.Errorf("got files %q, want %q", s.Files, tt.files)
+			}
+			if got := describeLines(s, s.Lines); tt.err == "" && !reflect.DeepEqual(got, tt.lines) {
+				t.Errorf("got lines\n%q\nwant\n%q", got, tt.lines)
+			}
+		})
+	}
+}
+
+func TestSourceOf(t *testing.T) {
+	tests := []struct {
+		code  string
+		lines []string
+	}{
+		{"", nil},
+		{"\n", []string{"a:1: "}},
+		{"one\ntwo", []string{"a:1: one", "a:2: two"}},
+		{"one\r\ntwo\r\n", []string{"a:1: one", "a:2: two"}},
+	}
+	for _, tt := range tests {
+		s := SourceOf("a", tt.code)
+		if got := describeLines(s, s.Lines); !reflect.DeepEqual(got, tt.lines) {
+			t.Errorf("SourceOf(%q) got %q, want %q", tt.code, got, tt.lines)
+		}
+	}
+}
@@ -0,0 +1 @@
+#define AMBIENT vec3(0.1)
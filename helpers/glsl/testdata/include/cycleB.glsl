@@ -0,0 +1 @@
+#include "cycleC.glsl"
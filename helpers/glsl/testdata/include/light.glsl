@@ -0,0 +1,8 @@
+#ifndef LIGHT_GLSL
+#define LIGHT_GLSL
+#include "common.glsl" // shared constants
+
+vec3 light() {
+	return AMBIENT;
+}
+#endif
@@ -0,0 +1,7 @@
+#version 330 core
+#include "include/light.glsl"
+out vec4 FragColor;
+
+void main() {
+	FragColor = vec4(light(), 1.0);
+}
@@ -0,0 +1,1049 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+/*
+A glTF 2.0 importer for .gltf (with embedded or external buffers)
+and binary .glb files.
+Parsing and decoding happen first without touching openGL,
+the meshes and images are only uploaded once everything is valid.
+*/
+
+type GLTFScene struct {
+	Nodes     []*GLTFNode //every node in the file
+	Roots     []*GLTFNode //the top level nodes of the scene that was loaded
+	Meshes    []*GLTFMesh
+	Materials []*GLTFMaterial
+	Textures  []TextureID
+
+	//bound in place of the textures a material doesn't have
+	whiteTexture      TextureID
+	flatNormalTexture TextureID
+}
+
+type GLTFNode struct {
+	Name     string
+	Local    mgl32.Mat4 //relative to the parent node
+	World    mgl32.Mat4 //relative to the scene root
+	Mesh     *GLTFMesh  //nil for nodes that only group others
+	Parent   *GLTFNode
+	Children []*GLTFNode
+}
+
+type GLTFMesh struct {
+	Name       string
+	Primitives []GLTFPrimitive
+}
+
+type GLTFPrimitive struct {
+	Mesh     *MeshData
+	Object   Object
+	Material *GLTFMaterial
+}
+
+// the parts of a pbrMetallicRoughness material our shaders can use
+// textures are nil when the material doesn't have them
+type GLTFMaterial struct {
+	Name string
+
+	BaseColorFactor  mgl32.Vec4
+	BaseColorTexture *TextureID
+
+	MetallicFactor           float32
+	RoughnessFactor          float32
+	MetallicRoughnessTexture *TextureID
+
+	NormalTexture *TextureID
+	NormalScale   float32
+
+	OcclusionTexture  *TextureID
+	OcclusionStrength float32
+
+	EmissiveFactor  mgl32.Vec3
+	EmissiveTexture *TextureID
+
+	DoubleSided bool
+}
+
+// draws every node that has a mesh, the base colour texture is
+// bound to texture unit 0 and the normal map to unit 1
+// primitives without them get plain white and a flat normal map
+func (s *GLTFScene) Draw(shader *Shader, drawMatrix mgl32.Mat4) {
+	for _, n := range s.Nodes {
+		if n.Mesh == nil {
+			continue
+		}
+		for _, p := range n.Mesh.Primitives {
+			baseColor, normal := s.whiteTexture, s.flatNormalTexture
+			if p.Material != nil {
+				if p.Material.BaseColorTexture != nil {
+					baseColor = *p.Material.BaseColorTexture
+				}
+				if p.Material.NormalTexture != nil {
+					normal = *p.Material.NormalTexture
+				}
+			}
+			BindTextureUnit(0, baseColor)
+			BindTextureUnit(1, normal)
+			p.Object.Draw(shader, drawMatrix.Mul4(n.World))
+		}
+	}
+}
+
+// frees the scene's textures and buffers
+func (s *GLTFScene) Delete() {
+	for _, m := range s.Meshes {
+		for i := range m.Primitives {
+			m.Primitives[i].Object.Delete()
+		}
+	}
+	for _, t := range s.Textures {
+		t.Delete()
+	}
+	s.whiteTexture.Delete()
+	s.flatNormalTexture.Delete()
+}
+
+// loads a .gltf or .glb file along with any buffers and images it references
+func LoadGLTF(path string) (*GLTFScene, error) {
+	doc, err := parseGLTFFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	scene, images, err := doc.build()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	doc.upload(scene, images)
+	return scene, nil
+}
+
+//the subset of the glTF json schema that the importer reads
+
+type gltfDocument struct {
+	Asset struct {
+		Version    string `json:"version"`
+		MinVersion string `json:"minVersion"`
+	} `json:"asset"`
+	ExtensionsUsed     []string `json:"extensionsUsed"`
+	ExtensionsRequired []string `json:"extensionsRequired"`
+
+	Scene  *int `json:"scene"`
+	Scenes []struct {
+		Nodes []int `json:"nodes"`
+	} `json:"scenes"`
+	Nodes []struct {
+		Name        string    `json:"name"`
+		Children    []int     `json:"children"`
+		Mesh        *int      `json:"mesh"`
+		Matrix      []float32 `json:"matrix"`
+		Translation []float32 `json:"translation"`
+		Rotation    []float32 `json:"rotation"`
+		Scale       []float32 `json:"scale"`
+	} `json:"nodes"`
+	Meshes []struct {
+		Name       string `json:"name"`
+		Primitives []struct {
+			Attributes map[string]int `json:"attributes"`
+			Indices    *int           `json:"indices"`
+			Material   *int           `json:"material"`
+			Mode       *int           `json:"mode"`
+			Targets    []any          `json:"targets"`
+		} `json:"primitives"`
+	} `json:"meshes"`
+	Materials []struct {
+		Name                 string `json:"name"`
+		PBRMetallicRoughness *struct {
+			BaseColorFactor          []float32       `json:"baseColorFactor"`
+			BaseColorTexture         *gltfTextureRef `json:"baseColorTexture"`
+			MetallicFactor           *float32        `json:"metallicFactor"`
+			RoughnessFactor          *float32        `json:"roughnessFactor"`
+			MetallicRoughnessTexture *gltfTextureRef `json:"metallicRoughnessTexture"`
+		} `json:"pbrMetallicRoughness"`
+		NormalTexture    *gltfTextureRef `json:"normalTexture"`
+		OcclusionTexture *gltfTextureRef `json:"occlusionTexture"`
+		EmissiveTexture  *gltfTextureRef `json:"emissiveTexture"`
+		EmissiveFactor   []float32       `json:"emissiveFactor"`
+		DoubleSided      bool            `json:"doubleSided"`
+	} `json:"materials"`
+	Textures []struct {
+		Sampler    *int       `json:"sampler"`
+		Source     *int       `json:"source"`
+		Extensions gltfExtMap `json:"extensions"`
+	} `json:"textures"`
+	Samplers []struct {
+		MagFilter *int32 `json:"magFilter"`
+		MinFilter *int32 `json:"minFilter"`
+		WrapS     *int32 `json:"wrapS"`
+		WrapT     *int32 `json:"wrapT"`
+	} `json:"samplers"`
+	Images []struct {
+		URI        string `json:"uri"`
+		MimeType   string `json:"mimeType"`
+		BufferView *int   `json:"bufferView"`
+	} `json:"images"`
+	Accessors []struct {
+		BufferView    *int   `json:"bufferView"`
+		ByteOffset    int    `json:"byteOffset"`
+		ComponentType int    `json:"componentType"`
+		Normalized    bool   `json:"normalized"`
+		Count         int    `json:"count"`
+		Type          string `json:"type"`
+		Sparse        any    `json:"sparse"`
+	} `json:"accessors"`
+	BufferViews []struct {
+		Buffer     int `json:"buffer"`
+		ByteOffset int `json:"byteOffset"`
+		ByteLength int `json:"byteLength"`
+		ByteStride int `json:"byteStride"`
+	} `json:"bufferViews"`
+	Buffers []struct {
+		URI        string `json:"uri"`
+		ByteLength int    `json:"byteLength"`
+	} `json:"buffers"`
+
+	dir     string   //used to resolve relative uris
+	glbBin  []byte   //the binary chunk of a .glb file
+	buffers [][]byte //loaded by loadBuffers
+}
+
+type gltfTextureRef struct {
+	Index    int     `json:"index"`
+	TexCoord int     `json:"texCoord"`
+	Scale    float32 `json:"scale"`
+	Strength float32 `json:"strength"`
+}
+
+// extension objects are never read, optional extensions are ignored and
+// files that require an unsupported one are rejected, this is only used
+// to explain missing images
+type gltfExtMap map[string]json.RawMessage
+
+// the extensions a file can require, quantized meshes only use the integer
+// attribute types readAccessor already converts
+var gltfSupportedExtensions = map[string]bool{
+	"KHR_mesh_quantization": true,
+}
+
+const (
+	glbMagic     = 0x46546C67 // "glTF"
+	glbChunkJSON = 0x4E4F534A // "JSON"
+	glbChunkBin  = 0x004E4942 // "BIN\0"
+)
+
+func parseGLTFFile(path string) (*gltfDocument, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := parseGLTF(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// parses either a json .gltf or a binary .glb
+// dir is where external buffers and images are loaded from
+func parseGLTF(data []byte, dir string) (*gltfDocument, error) {
+	jsonData := data
+	var bin []byte
+
+	if len(data) >= 4 && binary.LittleEndian.Uint32(data) == glbMagic {
+		var err error
+		jsonData, bin, err = splitGLB(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	doc := gltfDocument{
+		dir:    dir,
+		glbBin: bin,
+	}
+	if err := json.Unmarshal(jsonData, &doc); err != nil {
+		return nil, fmt.Errorf("invalid gltf json: %w", err)
+	}
+
+	if !strings.HasPrefix(doc.Asset.Version, "2.") {
+		return nil, fmt.Errorf("unsupported gltf version %q, only 2.x is supported", doc.Asset.Version)
+	}
+	var unsupported []string
+	for _, ext := range doc.ExtensionsRequired {
+		if !slices.Contains(doc.ExtensionsUsed, ext) {
+			return nil, fmt.Errorf("required extension %s isn't in extensionsUsed", ext)
+		}
+		if !gltfSupportedExtensions[ext] {
+			unsupported = append(unsupported, ext)
+		}
+	}
+	if len(unsupported) > 0 {
+		return nil, fmt.Errorf("unsupported required extensions: %s", strings.Join(unsupported, ", "))
+	}
+
+	if err := doc.loadBuffers(); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func splitGLB(data []byte) (jsonChunk, binChunk []byte, err error) {
+	if len(data) < 12 {
+		return nil, nil, fmt.Errorf("glb header is truncated")
+	}
+	if version := binary.LittleEndian.Uint32(data[4:]); version != 2 {
+		return nil, nil, fmt.Errorf("unsupported glb version %d", version)
+	}
+	length := int(binary.LittleEndian.Uint32(data[8:]))
+	if length > len(data) {
+		return nil, nil, fmt.Errorf("glb says it is %d bytes but only %d were read", length, len(data))
+	}
+
+	offset := 12
+	for offset+8 <= length {
+		chunkLength := int(binary.LittleEndian.Uint32(data[offset:]))
+		chunkType := binary.LittleEndian.Uint32(data[offset+4:])
+		offset += 8
+		if offset+chunkLength > length {
+			return nil, nil, fmt.Errorf("glb chunk runs past the end of the file")
+		}
+		chunk := data[offset : offset+chunkLength]
+		offset += chunkLength
+
+		switch chunkType {
+		case glbChunkJSON:
+			if jsonChunk == nil {
+				jsonChunk = chunk
+			}
+		case glbChunkBin:
+			if binChunk == nil {
+				binChunk = chunk
+			}
+		}
+		//unknown chunk types must be ignored
+	}
+
+	if jsonChunk == nil {
+		return nil, nil, fmt.Errorf("glb has no JSON chunk")
+	}
+	return jsonChunk, binChunk, nil
+}
+
+func (doc *gltfDocument) loadBuffers() error {
+	doc.buffers = make([][]byte, len(doc.Buffers))
+	for i, b := range doc.Buffers {
+		var data []byte
+		if b.URI == "" {
+			if i != 0 || doc.glbBin == nil {
+				return fmt.Errorf("buffer %d has no uri", i)
+			}
+			data = doc.glbBin
+		} else {
+			var err error
+			data, err = doc.readURI(b.URI)
+			if err != nil {
+				return fmt.Errorf("buffer %d: %w", i, err)
+			}
+		}
+
+		if b.ByteLength < 0 {
+			return fmt.Errorf("buffer %d has a negative length", i)
+		}
+		if len(data) < b.ByteLength {
+			return fmt.Errorf("buffer %d should be %d bytes but is %d", i, b.ByteLength, len(data))
+		}
+		doc.buffers[i] = data[:b.ByteLength]
+	}
+	return nil
+}
+
+// reads a data: uri or a file relative to the gltf file
+func (doc *gltfDocument) readURI(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		comma := strings.IndexByte(uri, ',')
+		if comma < 0 || !strings.HasSuffix(uri[:comma], ";base64") {
+			return nil, fmt.Errorf("only base64 data uris are supported")
+		}
+		return base64.StdEncoding.DecodeString(uri[comma+1:])
+	}
+
+	path, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.Join(doc.dir, filepath.FromSlash(path)))
+}
+
+func (doc *gltfDocument) bufferView(index int) ([]byte, int, error) {
+	if index < 0 || index >= len(doc.BufferViews) {
+		return nil, 0, fmt.Errorf("buffer view %d doesn't exist", index)
+	}
+	view := doc.BufferViews[index]
+	if view.Buffer < 0 || view.Buffer >= len(doc.buffers) {
+		return nil, 0, fmt.Errorf("buffer view %d uses missing buffer %d", index, view.Buffer)
+	}
+	buffer := doc.buffers[view.Buffer]
+	if view.ByteOffset < 0 || view.ByteLength < 0 || view.ByteStride < 0 {
+		return nil, 0, fmt.Errorf("buffer view %d has a negative offset, length or stride", index)
+	}
+	if view.ByteOffset+view.ByteLength > len(buffer) {
+		return nil, 0, fmt.Errorf("buffer view %d runs past the end of buffer %d", index, view.Buffer)
+	}
+	return buffer[view.ByteOffset : view.ByteOffset+view.ByteLength], view.ByteStride, nil
+}
+
+var gltfComponentCounts = map[string]int{
+	"SCALAR": 1,
+	"VEC2":   2,
+	"VEC3":   3,
+	"VEC4":   4,
+	"MAT2":   4,
+	"MAT3":   9,
+	"MAT4":   16,
+}
+
+const (
+	gltfByte          = 5120
+	gltfUnsignedByte  = 5121
+	gltfShort         = 5122
+	gltfUnsignedShort = 5123
+	gltfUnsignedInt   = 5125
+	gltfFloat         = 5126
+)
+
+var gltfComponentSizes = map[int]int{
+	gltfByte:          1,
+	gltfUnsignedByte:  1,
+	gltfShort:         2,
+	gltfUnsignedShort: 2,
+	gltfUnsignedInt:   4,
+	gltfFloat:         4,
+}
+
+// reads an accessor as float32s, integer types are converted
+// (and normalized to 0..1 or -1..1 if the accessor asks for it)
+// returns the values and the number of components per element
+func (doc *gltfDocument) readAccessor(index int) ([]float32, int, error) {
+	if index < 0 || index >= len(doc.Accessors) {
+		return nil, 0, fmt.Errorf("accessor %d doesn't exist", index)
+	}
+	a := doc.Accessors[index]
+	if a.Count < 0 || a.ByteOffset < 0 {
+		return nil, 0, fmt.Errorf("accessor %d has a negative count or offset", index)
+	}
+	if a.Sparse != nil {
+		return nil, 0, fmt.Errorf("accessor %d is sparse which isn't supported", index)
+	}
+	components, ok := gltfComponentCounts[a.Type]
+	if !ok {
+		return nil, 0, fmt.Errorf("accessor %d has unknown type %q", index, a.Type)
+	}
+	size, ok := gltfComponentSizes[a.ComponentType]
+	if !ok {
+		return nil, 0, fmt.Errorf("accessor %d has unknown component type %d", index, a.ComponentType)
+	}
+
+	if a.BufferView == nil {
+		//accessors without a view are all zeros
+		return make([]float32, a.Count*components), components, nil
+	}
+
+	data, stride, err := doc.bufferView(*a.BufferView)
+	if err != nil {
+		return nil, 0, fmt.Errorf("accessor %d: %w", index, err)
+	}
+	elementSize := size * components
+	if stride == 0 {
+		stride = elementSize
+	}
+	if !accessorFits(a.ByteOffset, a.Count, stride, elementSize, len(data)) {
+		return nil, 0, fmt.Errorf("accessor %d runs past the end of its buffer view", index)
+	}
+
+	values := make([]float32, a.Count*components)
+	for e := 0; e < a.Count; e++ {
+		for c := 0; c < components; c++ {
+			offset := a.ByteOffset + e*stride + c*size
+			values[e*components+c] = readGLTFComponent(data[offset:], a.ComponentType, a.Normalized)
+		}
+	}
+	return values, components, nil
+}
+
+// checks count elements stride apart fit in a view of length bytes
+// before anything is allocated, without multiplying so a huge count can't overflow
+func accessorFits(offset, count, stride, elementSize, length int) bool {
+	if count == 0 {
+		return true
+	}
+	last := length - offset - elementSize //where the last element can start
+	return last >= 0 && count-1 <= last/stride
+}
+
+func readGLTFComponent(data []byte, componentType int, normalized bool) float32 {
+	switch componentType {
+	case gltfByte:
+		v := int8(data[0])
+		if normalized {
+			return max(float32(v)/127, -1)
+		}
+		return float32(v)
+	case gltfUnsignedByte:
+		if normalized {
+			return float32(data[0]) / 255
+		}
+		return float32(data[0])
+	case gltfShort:
+		v := int16(binary.LittleEndian.Uint16(data))
+		if normalized {
+			return max(float32(v)/32767, -1)
+		}
+		return float32(v)
+	case gltfUnsignedShort:
+		v := binary.LittleEndian.Uint16(data)
+		if normalized {
+			return float32(v) / 65535
+		}
+		return float32(v)
+	case gltfUnsignedInt:
+		return float32(binary.LittleEndian.Uint32(data))
+	default:
+		return math.Float32frombits(binary.LittleEndian.Uint32(data))
+	}
+}
+
+// indices are read separately so large uint32 values don't lose precision
+func (doc *gltfDocument) readIndices(index int) ([]uint32, error) {
+	if index < 0 || index >= len(doc.Accessors) {
+		return nil, fmt.Errorf("accessor %d doesn't exist", index)
+	}
+	a := doc.Accessors[index]
+	if a.Count < 0 || a.ByteOffset < 0 {
+		return nil, fmt.Errorf("accessor %d has a negative count or offset", index)
+	}
+	if a.Type != "SCALAR" {
+		return nil, fmt.Errorf("index accessor %d must be SCALAR not %s", index, a.Type)
+	}
+	if a.ComponentType == gltfFloat || a.ComponentType == gltfByte || a.ComponentType == gltfShort {
+		return nil, fmt.Errorf("index accessor %d has invalid component type %d", index, a.ComponentType)
+	}
+	if a.ComponentType != gltfUnsignedInt {
+		values, _, err := doc.readAccessor(index)
+		if err != nil {
+			return nil, err
+		}
+		indices := make([]uint32, len(values))
+		for i, v := range values {
+			indices[i] = uint32(v)
+		}
+		return indices, nil
+	}
+
+	if a.Sparse != nil {
+		return nil, fmt.Errorf("accessor %d is sparse which isn't supported", index)
+	}
+	if a.BufferView == nil {
+		return make([]uint32, a.Count), nil
+	}
+	data, stride, err := doc.bufferView(*a.BufferView)
+	if err != nil {
+		return nil, fmt.Errorf("accessor %d: %w", index, err)
+	}
+	if stride == 0 {
+		stride = 4
+	}
+	if !accessorFits(a.ByteOffset, a.Count, stride, 4, len(data)) {
+		return nil, fmt.Errorf("accessor %d runs past the end of its buffer view", index)
+	}
+	indices := make([]uint32, a.Count)
+	for i := range indices {
+		indices[i] = binary.LittleEndian.Uint32(data[a.ByteOffset+i*stride:])
+	}
+	return indices, nil
+}
+
+// a decoded image and the sampler it should be uploaded with
+type gltfImage struct {
+	img     image.Image
+	sampler int //-1 for the default sampler
+}
+
+// builds everything apart from the GL objects
+// the returned images line up with scene.Textures
+func (doc *gltfDocument) build() (*GLTFScene, []gltfImage, error) {
+	scene := GLTFScene{}
+
+	images, err := doc.decodeTextures()
+	if err != nil {
+		return nil, nil, err
+	}
+	//filled in with real IDs once they are uploaded
+	scene.Textures = make([]TextureID, len(images))
+
+	for i := range doc.Materials {
+		m, err := doc.buildMaterial(i, &scene)
+		if err != nil {
+			return nil, nil, err
+		}
+		scene.Materials = append(scene.Materials, m)
+	}
+
+	for i := range doc.Meshes {
+		m, err := doc.buildMesh(i, &scene)
+		if err != nil {
+			return nil, nil, err
+		}
+		scene.Meshes = append(scene.Meshes, m)
+	}
+
+	if err := doc.buildNodes(&scene); err != nil {
+		return nil, nil, err
+	}
+	return &scene, images, nil
+}
+
+func (doc *gltfDocument) decodeTextures() ([]gltfImage, error) {
+	decoded := make(map[int]image.Image)
+	images := make([]gltfImage, len(doc.Textures))
+
+	for i, t := range doc.Textures {
+		if t.Source == nil && len(t.Extensions) > 0 {
+			for name := range t.Extensions {
+				return nil, fmt.Errorf("texture %d only has an image through unsupported extension %s", i, name)
+			}
+		}
+		if t.Source == nil {
+			return nil, fmt.Errorf("texture %d has no image", i)
+		}
+		source := *t.Source
+		if source < 0 || source >= len(doc.Images) {
+			return nil, fmt.Errorf("texture %d uses missing image %d", i, source)
+		}
+
+		img, ok := decoded[source]
+		if !ok {
+			var err error
+			img, err = doc.decodeImage(source)
+			if err != nil {
+				return nil, err
+			}
+			decoded[source] = img
+		}
+
+		images[i] = gltfImage{img: img, sampler: -1}
+		if t.Sampler != nil {
+			if *t.Sampler < 0 || *t.Sampler >= len(doc.Samplers) {
+				return nil, fmt.Errorf("texture %d uses missing sampler %d", i, *t.Sampler)
+			}
+			images[i].sampler = *t.Sampler
+		}
+	}
+	return images, nil
+}
+
+func (doc *gltfDocument) decodeImage(index int) (image.Image, error) {
+	i := doc.Images[index]
+
+	var data []byte
+	var err error
+	switch {
+	case i.BufferView != nil:
+		data, _, err = doc.bufferView(*i.BufferView)
+	case i.URI != "":
+		data, err = doc.readURI(i.URI)
+	default:
+		err = fmt.Errorf("has no uri or buffer view")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("image %d: %w", index, err)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("image %d: %w", index, err)
+	}
+	if format != "png" && format != "jpeg" {
+		return nil, fmt.Errorf("image %d is %s, only png and jpeg are supported", index, format)
+	}
+	return img, nil
+}
+
+func (doc *gltfDocument) buildMaterial(index int, scene *GLTFScene) (*GLTFMaterial, error) {
+	src := doc.Materials[index]
+	m := GLTFMaterial{
+		Name:              src.Name,
+		BaseColorFactor:   mgl32.Vec4{1, 1, 1, 1},
+		MetallicFactor:    1,
+		RoughnessFactor:   1,
+		NormalScale:       1,
+		OcclusionStrength: 1,
+		DoubleSided:       src.DoubleSided,
+	}
+
+	texture := func(ref *gltfTextureRef, name string) (*TextureID, error) {
+		if ref == nil {
+			return nil, nil
+		}
+		if ref.Index < 0 || ref.Index >= len(scene.Textures) {
+			return nil, fmt.Errorf("material %d %s uses missing texture %d", index, name, ref.Index)
+		}
+		if ref.TexCoord != 0 {
+			return nil, fmt.Errorf("material %d %s uses TEXCOORD_%d, only TEXCOORD_0 is supported", index, name, ref.TexCoord)
+		}
+		return &scene.Textures[ref.Index], nil
+	}
+
+	var err error
+	if pbr := src.PBRMetallicRoughness; pbr != nil {
+		if len(pbr.BaseColorFactor) == 4 {
+			m.BaseColorFactor = mgl32.Vec4(pbr.BaseColorFactor)
+		}
+		if pbr.MetallicFactor != nil {
+			m.MetallicFactor = *pbr.MetallicFactor
+		}
+		if pbr.RoughnessFactor != nil {
+			m.RoughnessFactor = *pbr.RoughnessFactor
+		}
+		if m.BaseColorTexture, err = texture(pbr.BaseColorTexture, "base colour"); err != nil {
+			return nil, err
+		}
+		if m.MetallicRoughnessTexture, err = texture(pbr.MetallicRoughnessTexture, "metallic roughness"); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.NormalTexture, err = texture(src.NormalTexture, "normal"); err != nil {
+		return nil, err
+	}
+	if src.NormalTexture != nil && src.NormalTexture.Scale != 0 {
+		m.NormalScale = src.NormalTexture.Scale
+	}
+	if m.OcclusionTexture, err = texture(src.OcclusionTexture, "occlusion"); err != nil {
+		return nil, err
+	}
+	if src.OcclusionTexture != nil && src.OcclusionTexture.Strength != 0 {
+		m.OcclusionStrength = src.OcclusionTexture.Strength
+	}
+	if m.EmissiveTexture, err = texture(src.EmissiveTexture, "emissive"); err != nil {
+		return nil, err
+	}
+	if len(src.EmissiveFactor) == 3 {
+		m.EmissiveFactor = mgl32.Vec3(src.EmissiveFactor)
+	}
+
+	return &m, nil
+}
+
+const (
+	gltfTriangles     = 4
+	gltfTriangleStrip = 5
+	gltfTriangleFan   = 6
+)
+
+func (doc *gltfDocument) buildMesh(index int, scene *GLTFScene) (*GLTFMesh, error) {
+	src := doc.Meshes[index]
+	mesh := GLTFMesh{Name: src.Name}
+
+	for p, prim := range src.Primitives {
+		errorf := func(format string, args ...any) error {
+			return fmt.Errorf("mesh %d primitive %d: %s", index, p, fmt.Sprintf(format, args...))
+		}
+
+		mode := gltfTriangles
+		if prim.Mode != nil {
+			mode = *prim.Mode
+		}
+		if mode != gltfTriangles && mode != gltfTriangleStrip && mode != gltfTriangleFan {
+			return nil, errorf("mode %d isn't supported, only triangles can be drawn", mode)
+		}
+		if len(prim.Targets) > 0 {
+			return nil, errorf("morph targets aren't supported")
+		}
+
+		data, err := doc.buildMeshData(prim.Attributes, prim.Indices, mode)
+		if err != nil {
+			return nil, errorf("%v", err)
+		}
+
+		var material *GLTFMaterial
+		if prim.Material != nil {
+			if *prim.Material < 0 || *prim.Material >= len(scene.Materials) {
+				return nil, errorf("uses missing material %d", *prim.Material)
+			}
+			material = scene.Materials[*prim.Material]
+		}
+
+		mesh.Primitives = append(mesh.Primitives, GLTFPrimitive{
+			Mesh:     data,
+			Material: material,
+		})
+	}
+	return &mesh, nil
+}
+
+func (doc *gltfDocument) buildMeshData(attributes map[string]int, indices *int, mode int) (*MeshData, error) {
+	if _, ok := attributes["POSITION"]; !ok {
+		return nil, fmt.Errorf("has no POSITION attribute")
+	}
+
+	read := func(name string, wantComponents int) ([]float32, error) {
+		values, components, err := doc.readAccessor(attributes[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if components != wantComponents {
+			return nil, fmt.Errorf("%s should have %d components but has %d", name, wantComponents, components)
+		}
+		return values, nil
+	}
+
+	m := MeshData{}
+	values, err := read("POSITION", 3)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(values); i += 3 {
+		m.Positions = append(m.Positions, mgl32.Vec3{values[i], values[i+1], values[i+2]})
+	}
+
+	m.UVs = make([]mgl32.Vec2, m.VertexCount())
+	if _, ok := attributes["TEXCOORD_0"]; ok {
+		values, err := read("TEXCOORD_0", 2)
+		if err != nil {
+			return nil, err
+		}
+		for i := range m.UVs {
+			//gltf puts the origin at the top left, our shaders expect bottom left
+			m.UVs[i] = mgl32.Vec2{values[i*2], 1 - values[i*2+1]}
+		}
+	}
+
+	if _, ok := attributes["NORMAL"]; ok {
+		values, err := read("NORMAL", 3)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(values); i += 3 {
+			m.Normals = append(m.Normals, mgl32.Vec3{values[i], values[i+1], values[i+2]})
+		}
+	}
+
+	if _, ok := attributes["TANGENT"]; ok && m.Normals != nil {
+		values, err := read("TANGENT", 4)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(values); i += 4 {
+			m.Tangents = append(m.Tangents, mgl32.Vec4{values[i], values[i+1], values[i+2], values[i+3]})
+		}
+	}
+
+	if indices != nil {
+		m.Indices, err = doc.readIndices(*indices)
+		if err != nil {
+			return nil, fmt.Errorf("indices: %w", err)
+		}
+	}
+	if mode != gltfTriangles {
+		m.ensureIndexed()
+		m.Indices = triangulateGLTFIndices(m.Indices, mode)
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// turns strip or fan indices into a plain triangle list
+func triangulateGLTFIndices(indices []uint32, mode int) []uint32 {
+	var triangles []uint32
+	for i := 2; i < len(indices); i++ {
+		switch mode {
+		case gltfTriangleStrip:
+			//every other triangle in a strip is wound backwards
+			if i%2 == 0 {
+				triangles = append(triangles, indices[i-2], indices[i-1], indices[i])
+			} else {
+				triangles = append(triangles, indices[i-1], indices[i-2], indices[i])
+			}
+		case gltfTriangleFan:
+			triangles = append(triangles, indices[i-1], indices[i], indices[0])
+		}
+	}
+	return triangles
+}
+
+func (doc *gltfDocument) buildNodes(scene *GLTFScene) error {
+	for i, src := range doc.Nodes {
+		n := GLTFNode{
+			Name:  src.Name,
+			Local: mgl32.Ident4(),
+		}
+
+		switch {
+		case len(src.Matrix) == 16:
+			n.Local = mgl32.Mat4(src.Matrix) //both are column major
+		case len(src.Matrix) != 0:
+			return fmt.Errorf("node %d matrix has %d values instead of 16", i, len(src.Matrix))
+		default:
+			t, r, s := mgl32.Ident4(), mgl32.Ident4(), mgl32.Ident4()
+			if len(src.Translation) == 3 {
+				t = mgl32.Translate3D(src.Translation[0], src.Translation[1], src.Translation[2])
+			}
+			if len(src.Rotation) == 4 {
+				q := mgl32.Quat{W: src.Rotation[3], V: mgl32.Vec3{src.Rotation[0], src.Rotation[1], src.Rotation[2]}}
+				r = q.Normalize().Mat4()
+			}
+			if len(src.Scale) == 3 {
+				s = mgl32.Scale3D(src.Scale[0], src.Scale[1], src.Scale[2])
+			}
+			n.Local = t.Mul4(r).Mul4(s)
+		}
+
+		if src.Mesh != nil {
+			if *src.Mesh < 0 || *src.Mesh >= len(scene.Meshes) {
+				return fmt.Errorf("node %d uses missing mesh %d", i, *src.Mesh)
+			}
+			n.Mesh = scene.Meshes[*src.Mesh]
+		}
+		scene.Nodes = append(scene.Nodes, &n)
+	}
+
+	for i, src := range doc.Nodes {
+		for _, c := range src.Children {
+			if c < 0 || c >= len(scene.Nodes) {
+				return fmt.Errorf("node %d has missing child %d", i, c)
+			}
+			child := scene.Nodes[c]
+			if child.Parent != nil || c == i {
+				return fmt.Errorf("node %d has more than one parent", c)
+			}
+			child.Parent = scene.Nodes[i]
+			scene.Nodes[i].Children = append(scene.Nodes[i].Children, child)
+		}
+	}
+
+	//use the default scene, or if there isn't one every node without a parent
+	var roots []int
+	sceneIndex := 0
+	if doc.Scene != nil {
+		sceneIndex = *doc.Scene
+	}
+	if sceneIndex >= 0 && sceneIndex < len(doc.Scenes) {
+		roots = doc.Scenes[sceneIndex].Nodes
+	} else if doc.Scene != nil {
+		return fmt.Errorf("default scene %d doesn't exist", sceneIndex)
+	} else {
+		for i, n := range scene.Nodes {
+			if n.Parent == nil {
+				roots = append(roots, i)
+			}
+		}
+	}
+
+	for _, r := range roots {
+		if r < 0 || r >= len(scene.Nodes) {
+			return fmt.Errorf("scene uses missing node %d", r)
+		}
+		root := scene.Nodes[r]
+		if root.Parent != nil {
+			return fmt.Errorf("scene root node %d has a parent", r)
+		}
+		scene.Roots = append(scene.Roots, root)
+	}
+
+	visited := make(map[*GLTFNode]bool)
+	var updateWorld func(n *GLTFNode, parent mgl32.Mat4) error
+	updateWorld = func(n *GLTFNode, parent mgl32.Mat4) error {
+		if visited[n] {
+			return fmt.Errorf("node %q is part of a cycle", n.Name)
+		}
+		visited[n] = true
+
+		n.World = parent.Mul4(n.Local)
+		for _, c := range n.Children {
+			if err := updateWorld(c, n.World); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, r := range scene.Roots {
+		if err := updateWorld(r, mgl32.Ident4()); err != nil {
+			return err
+		}
+	}
+
+	//only draw the nodes that are part of the scene
+	inScene := scene.Nodes[:0:0]
+	for _, n := range scene.Nodes {
+		if visited[n] {
+			inScene = append(inScene, n)
+		}
+	}
+	scene.Nodes = inScene
+	return nil
+}
+
+// creates the openGL textures and buffers for a built scene
+func (doc *gltfDocument) upload(scene *GLTFScene, images []gltfImage) {
+	for i, img := range images {
+		scene.Textures[i] = TextureFromImage(img.img)
+		if img.sampler < 0 {
+			continue
+		}
+
+		s := doc.Samplers[img.sampler]
+		if s.WrapS != nil {
+			backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, *s.WrapS)
+		}
+		if s.WrapT != nil {
+			backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, *s.WrapT)
+		}
+		if s.MinFilter != nil {
+			backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, *s.MinFilter)
+		}
+		if s.MagFilter != nil {
+			backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, *s.MagFilter)
+		}
+	}
+
+	for _, m := range scene.Meshes {
+		for i := range m.Primitives {
+			m.Primitives[i].Object = m.Primitives[i].Mesh.Upload()
+		}
+	}
+
+	scene.whiteTexture = solidTexture(color.RGBA{255, 255, 255, 255})
+	scene.flatNormalTexture = solidTexture(color.RGBA{128, 128, 255, 255})
+}
+
+// a 1x1 texture of one colour
+func solidTexture(c color.RGBA) TextureID {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.SetRGBA(0, 0, c)
+	return TextureFromImage(img)
+}
@@ -0,0 +1,348 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/png"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// the little endian bytes of each value in turn
+func gltfBytes(values ...any) []byte {
+	var b bytes.Buffer
+	for _, v := range values {
+		if err := binary.Write(&b, binary.LittleEndian, v); err != nil {
+			panic(err)
+		}
+	}
+	return b.Bytes()
+}
+
+func gltfDataURI(mime string, data []byte) string {
+	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// a document with buffer 0 embedded, rest is the other top level json properties
+func parseTestGLTF(buffer []byte, rest string) (*gltfDocument, error) {
+	json := fmt.Sprintf(`{
+		"asset": {"version": "2.0"},
+		"buffers": [{"uri": %q, "byteLength": %d}],
+		%s
+	}`, gltfDataURI("application/octet-stream", buffer), len(buffer), rest)
+	return parseGLTF([]byte(json), "")
+}
+
+func TestReadGLTFAccessor(t *testing.T) {
+	tests := []struct {
+		name       string
+		buffer     []byte
+		views      string
+		accessor   string
+		want       []float32
+		components int
+		err        string
+	}{
+		{
+			name:       "packed floats",
+			buffer:     gltfBytes(float32(1), float32(2), float32(3), float32(4)),
+			views:      `{"buffer": 0, "byteLength": 16}`,
+			accessor:   `{"bufferView": 0, "componentType": 5126, "count": 2, "type": "VEC2"}`,
+			want:       []float32{1, 2, 3, 4},
+			components: 2,
+		},
+		{
+			//every element is followed by a float that belongs to something else
+			name:       "view offset and stride",
+			buffer:     gltfBytes(float32(99), float32(1), float32(2), float32(99), float32(3), float32(4), float32(99), float32(5), float32(6)),
+			views:      `{"buffer": 0, "byteOffset": 4, "byteLength": 32, "byteStride": 12}`,
+			accessor:   `{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC2"}`,
+			want:       []float32{1, 2, 3, 4, 5, 6},
+			components: 2,
+		},
+		{
+			name:       "accessor offset within the stride",
+			buffer:     gltfBytes(float32(99), float32(1), float32(2), float32(99), float32(3), float32(4), float32(99), float32(5), float32(6)),
+			views:      `{"buffer": 0, "byteOffset": 4, "byteLength": 32, "byteStride": 12}`,
+			accessor:   `{"bufferView": 0, "byteOffset": 4, "componentType": 5126, "count": 2, "type": "VEC2"}`,
+			want:       []float32{2, 99, 4, 99},
+			components: 2,
+		},
+		{
+			name:     "offset pushes the last element past the view",
+			buffer:   gltfBytes(float32(99), float32(1), float32(2), float32(99), float32(3), float32(4), float32(99), float32(5), float32(6)),
+			views:    `{"buffer": 0, "byteOffset": 4, "byteLength": 32, "byteStride": 12}`,
+			accessor: `{"bufferView": 0, "byteOffset": 4, "componentType": 5126, "count": 3, "type": "VEC2"}`,
+			err:      "accessor 0 runs past the end of its buffer view",
+		},
+		{
+			name:       "normalized unsigned bytes",
+			buffer:     gltfBytes(uint8(0), uint8(255), uint8(51), uint8(0)),
+			views:      `{"buffer": 0, "byteLength": 4}`,
+			accessor:   `{"bufferView": 0, "componentType": 5121, "normalized": true, "count": 3, "type": "SCALAR"}`,
+			want:       []float32{0, 1, 0.2},
+			components: 1,
+		},
+		{
+			//-128 would be past -1 so it's clamped
+			name:       "normalized bytes",
+			buffer:     gltfBytes(int8(-128), int8(-127), int8(0), int8(127)),
+			views:      `{"buffer": 0, "byteLength": 4}`,
+			accessor:   `{"bufferView": 0, "componentType": 5120, "normalized": true, "count": 4, "type": "SCALAR"}`,
+			want:       []float32{-1, -1, 0, 1},
+			components: 1,
+		},
+		{
+			name:       "normalized shorts",
+			buffer:     gltfBytes(int16(-32768), int16(16384), int16(32767), int16(0)),
+			views:      `{"buffer": 0, "byteLength": 8}`,
+			accessor:   `{"bufferView": 0, "componentType": 5122, "normalized": true, "count": 3, "type": "SCALAR"}`,
+			want:       []float32{-1, 16384.0 / 32767, 1},
+			components: 1,
+		},
+		{
+			name:       "normalized unsigned shorts",
+			buffer:     gltfBytes(uint16(0), uint16(65535)),
+			views:      `{"buffer": 0, "byteLength": 4}`,
+			accessor:   `{"bufferView": 0, "componentType": 5123, "normalized": true, "count": 2, "type": "SCALAR"}`,
+			want:       []float32{0, 1},
+			components: 1,
+		},
+		{
+			name:       "unnormalized unsigned shorts",
+			buffer:     gltfBytes(uint16(7), uint16(65535)),
+			views:      `{"buffer": 0, "byteLength": 4}`,
+			accessor:   `{"bufferView": 0, "componentType": 5123, "count": 2, "type": "SCALAR"}`,
+			want:       []float32{7, 65535},
+			components: 1,
+		},
+		{
+			name:       "no buffer view",
+			buffer:     gltfBytes(float32(1)),
+			views:      `{"buffer": 0, "byteLength": 4}`,
+			accessor:   `{"componentType": 5126, "count": 2, "type": "VEC3"}`,
+			want:       []float32{0, 0, 0, 0, 0, 0},
+			components: 3,
+		},
+		{
+			name:     "past the end",
+			buffer:   gltfBytes(float32(1), float32(2)),
+			views:    `{"buffer": 0, "byteLength": 8}`,
+			accessor: `{"bufferView": 0, "componentType": 5126, "count": 3, "type": "SCALAR"}`,
+			err:      "accessor 0 runs past the end of its buffer view",
+		},
+		{
+			//caught before trying to allocate terabytes
+			name:     "huge count",
+			buffer:   gltfBytes(float32(1), float32(2)),
+			views:    `{"buffer": 0, "byteLength": 8}`,
+			accessor: `{"bufferView": 0, "componentType": 5126, "count": 1099511627776, "type": "MAT4"}`,
+			err:      "accessor 0 runs past the end of its buffer view",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parseTestGLTF(tt.buffer, fmt.Sprintf(`"bufferViews": [%s], "accessors": [%s]`, tt.views, tt.accessor))
+			if err != nil {
+				t.Fatal(err)
+			}
+			values, components, err := doc.readAccessor(0)
+			if got := errorText(err); got != tt.err {
+				t.Fatalf("got error %q, want %q", got, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if components != tt.components {
+				t.Errorf("got %d components, want %d", components, tt.components)
+			}
+			if len(values) != len(tt.want) {
+				t.Fatalf("got %v, want %v", values, tt.want)
+			}
+			for i := range values {
+				if !mgl32.FloatEqualThreshold(values[i], tt.want[i], 1e-6) {
+					t.Errorf("got %v, want %v", values, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestReadGLTFIndices(t *testing.T) {
+	doc, err := parseTestGLTF(gltfBytes(uint32(4000000000), uint32(0), uint32(1), uint32(2)), `
+		"bufferViews": [{"buffer": 0, "byteLength": 16}],
+		"accessors": [
+			{"bufferView": 0, "componentType": 5125, "count": 4, "type": "SCALAR"},
+			{"bufferView": 0, "byteOffset": 4, "componentType": 5125, "count": 4, "type": "SCALAR"},
+			{"bufferView": 0, "componentType": 5125, "count": 1099511627776, "type": "SCALAR"}
+		]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//too big for a float32 to hold exactly
+	if got, err := doc.readIndices(0); err != nil || !reflect.DeepEqual(got, []uint32{4000000000, 0, 1, 2}) {
+		t.Errorf("got %v, %v", got, err)
+	}
+	for _, accessor := range []int{1, 2} {
+		if _, err := doc.readIndices(accessor); errorText(err) != fmt.Sprintf("accessor %d runs past the end of its buffer view", accessor) {
+			t.Errorf("accessor %d got error %v", accessor, err)
+		}
+	}
+}
+
+func TestParseGLTFExtensions(t *testing.T) {
+	tests := []struct {
+		name       string
+		extensions string
+		err        string
+	}{
+		{
+			name:       "supported required extension",
+			extensions: `"extensionsUsed": ["KHR_mesh_quantization"], "extensionsRequired": ["KHR_mesh_quantization"]`,
+		},
+		{
+			name:       "unsupported optional extension",
+			extensions: `"extensionsUsed": ["KHR_materials_unlit"]`,
+		},
+		{
+			name:       "unsupported required extension",
+			extensions: `"extensionsUsed": ["KHR_mesh_quantization", "KHR_draco_mesh_compression"], "extensionsRequired": ["KHR_mesh_quantization", "KHR_draco_mesh_compression"]`,
+			err:        "unsupported required extensions: KHR_draco_mesh_compression",
+		},
+		{
+			name:       "required but not used",
+			extensions: `"extensionsRequired": ["KHR_mesh_quantization"]`,
+			err:        "required extension KHR_mesh_quantization isn't in extensionsUsed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTestGLTF(nil, tt.extensions)
+			if got := errorText(err); got != tt.err {
+				t.Errorf("got error %q, want %q", got, tt.err)
+			}
+		})
+	}
+}
+
+func TestGLTFStripsAndFans(t *testing.T) {
+	square := gltfBytes(
+		float32(0), float32(0), float32(0),
+		float32(1), float32(0), float32(0),
+		float32(0), float32(1), float32(0),
+		float32(1), float32(1), float32(0),
+	)
+	doc, err := parseTestGLTF(square, `
+		"bufferViews": [{"buffer": 0, "byteLength": 48}],
+		"accessors": [{"bufferView": 0, "componentType": 5126, "count": 4, "type": "VEC3"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		mode int
+		want []uint32
+	}{
+		{"triangles", gltfTriangles, nil},
+		//the second triangle is flipped back so both face the same way
+		{"strip", gltfTriangleStrip, []uint32{0, 1, 2, 2, 1, 3}},
+		{"fan", gltfTriangleFan, []uint32{1, 2, 0, 2, 3, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.mode == gltfTriangles {
+				//4 verticies isn't a whole number of triangles
+				if _, err := doc.buildMeshData(map[string]int{"POSITION": 0}, nil, tt.mode); err == nil {
+					t.Error("4 verticies were accepted as a triangle list")
+				}
+				return
+			}
+			m, err := doc.buildMeshData(map[string]int{"POSITION": 0}, nil, tt.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(m.Indices, tt.want) {
+				t.Errorf("got indices %v, want %v", m.Indices, tt.want)
+			}
+		})
+	}
+
+	if got := triangulateGLTFIndices([]uint32{5, 6, 7, 8, 9}, gltfTriangleStrip); !reflect.DeepEqual(got, []uint32{5, 6, 7, 7, 6, 8, 7, 8, 9}) {
+		t.Errorf("longer strip got %v", got)
+	}
+	if got := triangulateGLTFIndices([]uint32{5, 6}, gltfTriangleFan); got != nil {
+		t.Errorf("fan of two indices got %v", got)
+	}
+}
+
+func TestGLTFDrawBindsDefaultTextures(t *testing.T) {
+	b := useRecordingBackend(t)
+
+	var pixel bytes.Buffer
+	if err := png.Encode(&pixel, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	triangle := gltfBytes(
+		float32(0), float32(0), float32(0),
+		float32(1), float32(0), float32(0),
+		float32(0), float32(1), float32(0),
+	)
+	//textured, then a material without textures, then no material at all
+	dir := t.TempDir()
+	path := filepath.Join(dir, "scene.gltf")
+	writeFile(t, path, fmt.Sprintf(`{
+		"asset": {"version": "2.0"},
+		"buffers": [{"uri": %q, "byteLength": 36}],
+		"bufferViews": [{"buffer": 0, "byteLength": 36}],
+		"accessors": [{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}],
+		"images": [{"uri": %q}],
+		"textures": [{"source": 0}, {"source": 0}],
+		"materials": [
+			{"pbrMetallicRoughness": {"baseColorTexture": {"index": 0}}, "normalTexture": {"index": 1}},
+			{}
+		],
+		"meshes": [
+			{"primitives": [{"attributes": {"POSITION": 0}, "material": 0}]},
+			{"primitives": [{"attributes": {"POSITION": 0}, "material": 1}]},
+			{"primitives": [{"attributes": {"POSITION": 0}}]}
+		],
+		"nodes": [{"mesh": 0}, {"mesh": 1}, {"mesh": 2}]
+	}`, gltfDataURI("application/octet-stream", triangle), gltfDataURI("image/png", pixel.Bytes())))
+
+	scene, err := LoadGLTF(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scene.Delete()
+	shader, _ := newTestShader(t, testVertSource, testFragSource)
+	shader.Use()
+	scene.Draw(shader, mgl32.Ident4())
+
+	if len(b.Draws) != 3 {
+		t.Fatalf("got %d draws, want 3", len(b.Draws))
+	}
+	if scene.whiteTexture == 0 || scene.flatNormalTexture == 0 {
+		t.Fatal("the default textures weren't made")
+	}
+	want := []map[uint32]uint32{
+		{0: uint32(scene.Textures[0]), 1: uint32(scene.Textures[1])},
+		{0: uint32(scene.whiteTexture), 1: uint32(scene.flatNormalTexture)},
+		{0: uint32(scene.whiteTexture), 1: uint32(scene.flatNormalTexture)},
+	}
+	for i, draw := range b.Draws {
+		if !reflect.DeepEqual(draw.Textures, want[i]) {
+			t.Errorf("draw %d had textures %v bound, want %v", i, draw.Textures, want[i])
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package helpers
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// the per instance attributes uploaded by DrawInstanced
+// they are bound to these shader inputs (see uber.vert with INSTANCED):
+//
+//	in mat4 aInstanceModel;
+//	in vec4 aInstanceColor;
+//	in float aInstanceTexture;
+type InstanceData struct {
+	Model        mgl32.Mat4
+	Color        mgl32.Vec4
+	TextureIndex float32
+}
+
+// draws every instance with a single draw call
+// the shader must read its model matrix from aInstanceModel instead of the model uniform
+func (o Object) DrawInstanced(shader *Shader, instances []InstanceData) {
+	if activeCuller != nil {
+		visible := make([]InstanceData, 0, len(instances))
+		for _, i := range instances {
+			if activeCuller.Visible(o, i.Model) {
+				visible = append(visible, i)
+			}
+		}
+		instances = visible
+	}
+	if len(instances) == 0 {
+		return
+	}
+
+	if !o.bindForDraw(shader, true) {
+		return
+	}
+	backend.BindBuffer(gl.ARRAY_BUFFER, uint32(o.instanceBuffer))
+	BufferData(gl.ARRAY_BUFFER, instances, gl.STREAM_DRAW)
+
+	if o.indexCount > 0 {
+		backend.DrawElementsInstanced(gl.TRIANGLES, int32(o.indexCount), gl.UNSIGNED_INT, 0, int32(len(instances)))
+	} else {
+		backend.DrawArraysInstanced(gl.TRIANGLES, 0, int32(o.vertexCount), int32(len(instances)))
+	}
+	countDrawn(len(instances))
+}
+
+// the instanced version of DrawMultiple, every instance is white and uses texture 0
+func (o Object) DrawMultipleInstanced(shader *Shader, num int, drawMatrix func(int) mgl32.Mat4) {
+	instances := make([]InstanceData, num)
+	for i := range instances {
+		instances[i] = InstanceData{
+			Model: drawMatrix(i),
+			Color: mgl32.Vec4{1, 1, 1, 1},
+		}
+	}
+	o.DrawInstanced(shader, instances)
+}
@@ -0,0 +1,347 @@
+package helpers
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// geometry that lives on the CPU so it can be built, transformed,
+// merged and checked without an openGL context
+// call Upload to turn it into an Object that can be drawn
+type MeshData struct {
+	Positions []mgl32.Vec3
+	UVs       []mgl32.Vec2
+	Normals   []mgl32.Vec3
+	Tangents  []mgl32.Vec4 //XYZ + handedness in W, generated on upload if missing
+	Indices   []uint32     //nil if the verticies are an unindexed triangle list
+}
+
+// builds a mesh from an unindexed XYZ UV array like the ones in Cube
+func meshFromVerticies(verticies []float32) *MeshData {
+	m := MeshData{}
+	for i := 0; i+4 < len(verticies); i += 5 {
+		m.Positions = append(m.Positions, mgl32.Vec3{verticies[i], verticies[i+1], verticies[i+2]})
+		m.UVs = append(m.UVs, mgl32.Vec2{verticies[i+3], verticies[i+4]})
+	}
+	return &m
+}
+
+func (m *MeshData) VertexCount() int {
+	return len(m.Positions)
+}
+
+func (m *MeshData) Indexed() bool {
+	return m.Indices != nil
+}
+
+// the number of verticies a draw call has to process
+func (m *MeshData) ElementCount() int {
+	if m.Indexed() {
+		return len(m.Indices)
+	}
+	return m.VertexCount()
+}
+
+// checks that the attributes line up and every index is in range
+func (m *MeshData) Validate() error {
+	count := m.VertexCount()
+	if len(m.UVs) != count {
+		return fmt.Errorf("mesh has %d positions but %d UVs", count, len(m.UVs))
+	}
+	if m.Normals != nil && len(m.Normals) != count {
+		return fmt.Errorf("mesh has %d positions but %d normals", count, len(m.Normals))
+	}
+	if m.Tangents != nil && len(m.Tangents) != count {
+		return fmt.Errorf("mesh has %d positions but %d tangents", count, len(m.Tangents))
+	}
+	if m.ElementCount()%3 != 0 {
+		return fmt.Errorf("mesh has %d verticies which isn't a whole number of triangles", m.ElementCount())
+	}
+	for i, index := range m.Indices {
+		if int(index) >= count {
+			return fmt.Errorf("index %d is %d but the mesh only has %d verticies", i, index, count)
+		}
+	}
+	for i, p := range m.Positions {
+		if math.IsNaN(float64(p.X())) || math.IsNaN(float64(p.Y())) || math.IsNaN(float64(p.Z())) {
+			return fmt.Errorf("position %d is NaN", i)
+		}
+	}
+	return nil
+}
+
+func (m *MeshData) Clone() *MeshData {
+	c := MeshData{
+		Positions: append([]mgl32.Vec3(nil), m.Positions...),
+		UVs:       append([]mgl32.Vec2(nil), m.UVs...),
+	}
+	if m.Normals != nil {
+		c.Normals = append([]mgl32.Vec3{}, m.Normals...)
+	}
+	if m.Tangents != nil {
+		c.Tangents = append([]mgl32.Vec4{}, m.Tangents...)
+	}
+	if m.Indices != nil {
+		c.Indices = append([]uint32{}, m.Indices...)
+	}
+	return &c
+}
+
+// moves the mesh into the space described by transform
+// normals and tangents are kept perpendicular to the surface and a
+// mirroring transform reverses the winding so front faces stay in front
+func (m *MeshData) Transform(transform mgl32.Mat4) {
+	normalMatrix := transform.Mat3().Inv().Transpose()
+	mirrored := transform.Mat3().Det() < 0
+
+	for i, p := range m.Positions {
+		m.Positions[i] = mgl32.TransformCoordinate(p, transform)
+	}
+	for i, n := range m.Normals {
+		m.Normals[i] = safeNormalize(normalMatrix.Mul3x1(n))
+	}
+	for i, t := range m.Tangents {
+		w := t.W()
+		if mirrored {
+			w = -w
+		}
+		m.Tangents[i] = safeNormalize(transform.Mat3().Mul3x1(t.Vec3())).Vec4(w)
+	}
+
+	if mirrored {
+		m.reverseWinding()
+	}
+}
+
+func (m *MeshData) reverseWinding() {
+	if m.Indexed() {
+		for i := 0; i+2 < len(m.Indices); i += 3 {
+			m.Indices[i+1], m.Indices[i+2] = m.Indices[i+2], m.Indices[i+1]
+		}
+		return
+	}
+
+	for i := 0; i+2 < m.VertexCount(); i += 3 {
+		m.swapVerticies(i+1, i+2)
+	}
+}
+
+func (m *MeshData) swapVerticies(a, b int) {
+	m.Positions[a], m.Positions[b] = m.Positions[b], m.Positions[a]
+	m.UVs[a], m.UVs[b] = m.UVs[b], m.UVs[a]
+	if m.Normals != nil {
+		m.Normals[a], m.Normals[b] = m.Normals[b], m.Normals[a]
+	}
+	if m.Tangents != nil {
+		m.Tangents[a], m.Tangents[b] = m.Tangents[b], m.Tangents[a]
+	}
+}
+
+// appends other onto the end of this mesh
+// attributes that only one of the meshes has are dropped
+func (m *MeshData) Merge(other *MeshData) {
+	if other.VertexCount() == 0 {
+		return
+	}
+	offset := uint32(m.VertexCount())
+
+	if m.Indexed() || other.Indexed() {
+		m.ensureIndexed()
+		if other.Indexed() {
+			for _, index := range other.Indices {
+				m.Indices = append(m.Indices, index+offset)
+			}
+		} else {
+			for i := 0; i < other.VertexCount(); i++ {
+				m.Indices = append(m.Indices, uint32(i)+offset)
+			}
+		}
+	}
+
+	bothHave := func(a, b int) bool {
+		return (a > 0 || offset == 0) && b > 0
+	}
+	if bothHave(len(m.Normals), len(other.Normals)) {
+		m.Normals = append(m.Normals, other.Normals...)
+	} else {
+		m.Normals = nil
+	}
+	if bothHave(len(m.Tangents), len(other.Tangents)) {
+		m.Tangents = append(m.Tangents, other.Tangents...)
+	} else {
+		m.Tangents = nil
+	}
+
+	m.Positions = append(m.Positions, other.Positions...)
+	m.UVs = append(m.UVs, other.UVs...)
+}
+
+func (m *MeshData) ensureIndexed() {
+	if m.Indexed() {
+		return
+	}
+	m.Indices = make([]uint32, m.VertexCount())
+	for i := range m.Indices {
+		m.Indices[i] = uint32(i)
+	}
+}
+
+// turns an indexed mesh back into an unindexed triangle list
+// so every corner can have its own attributes
+func (m *MeshData) Expand() {
+	if !m.Indexed() {
+		return
+	}
+
+	expanded := MeshData{
+		Positions: make([]mgl32.Vec3, len(m.Indices)),
+		UVs:       make([]mgl32.Vec2, len(m.Indices)),
+	}
+	if m.Normals != nil {
+		expanded.Normals = make([]mgl32.Vec3, len(m.Indices))
+	}
+	if m.Tangents != nil {
+		expanded.Tangents = make([]mgl32.Vec4, len(m.Indices))
+	}
+	for i, index := range m.Indices {
+		expanded.Positions[i] = m.Positions[index]
+		expanded.UVs[i] = m.UVs[index]
+		if m.Normals != nil {
+			expanded.Normals[i] = m.Normals[index]
+		}
+		if m.Tangents != nil {
+			expanded.Tangents[i] = m.Tangents[index]
+		}
+	}
+	*m = expanded
+}
+
+// merges verticies that have identical attributes and indexes the rest
+func (m *MeshData) Weld() {
+	m.Expand()
+
+	unique := make(map[string]uint32)
+	welded := MeshData{
+		Indices: make([]uint32, 0, m.VertexCount()),
+	}
+	if m.Normals != nil {
+		welded.Normals = []mgl32.Vec3{}
+	}
+	if m.Tangents != nil {
+		welded.Tangents = []mgl32.Vec4{}
+	}
+
+	var key strings.Builder
+	for v := range m.Positions {
+		key.Reset()
+		writeFloatKeys(&key, m.Positions[v][:])
+		writeFloatKeys(&key, m.UVs[v][:])
+		if m.Normals != nil {
+			writeFloatKeys(&key, m.Normals[v][:])
+		}
+		if m.Tangents != nil {
+			writeFloatKeys(&key, m.Tangents[v][:])
+		}
+
+		index, ok := unique[key.String()]
+		if !ok {
+			index = uint32(welded.VertexCount())
+			unique[key.String()] = index
+
+			welded.Positions = append(welded.Positions, m.Positions[v])
+			welded.UVs = append(welded.UVs, m.UVs[v])
+			if m.Normals != nil {
+				welded.Normals = append(welded.Normals, m.Normals[v])
+			}
+			if m.Tangents != nil {
+				welded.Tangents = append(welded.Tangents, m.Tangents[v])
+			}
+		}
+		welded.Indices = append(welded.Indices, index)
+	}
+	*m = welded
+}
+
+func writeFloatKeys(b *strings.Builder, values []float32) {
+	for _, f := range values {
+		if f == 0 {
+			f = 0 //treat -0 and +0 as the same value
+		}
+		bits := math.Float32bits(f)
+		b.WriteByte(byte(bits))
+		b.WriteByte(byte(bits >> 8))
+		b.WriteByte(byte(bits >> 16))
+		b.WriteByte(byte(bits >> 24))
+	}
+}
+
+// replaces the normals and rewelds the mesh
+// as flat and creased normals can split verticies that were shared
+// the tangents are cleared as they depend on the normals
+func (m *MeshData) CalcNormals(opts NormalOptions) {
+	wasIndexed := m.Indexed()
+	m.Expand()
+
+	m.Normals = GenerateNormals(m.Positions, nil, opts)
+	m.Tangents = nil
+
+	if wasIndexed {
+		m.Weld()
+	}
+}
+
+func (m *MeshData) CalcTangents() {
+	if m.Normals == nil {
+		m.CalcNormals(NormalOptions{Mode: FlatNormals})
+	}
+	m.Tangents = GenerateTangents(m.Positions, m.UVs, m.Normals, m.Indices)
+}
+
+// the vertex format Object uploads
+type meshVertex struct {
+	Pos       mgl32.Vec3 `attr:"position"`
+	UV        mgl32.Vec2 `attr:"uv"`
+	Normal    mgl32.Vec3 `attr:"normal"`
+	Tangent   mgl32.Vec4 `attr:"tangent"`
+	Bitangent mgl32.Vec3 `attr:"bitangent"`
+}
+
+// the interleaved verticies that get uploaded into the VBO
+func (m *MeshData) verticies() []meshVertex {
+	verticies := make([]meshVertex, m.VertexCount())
+	for i := range verticies {
+		verticies[i] = meshVertex{
+			Pos:       m.Positions[i],
+			UV:        m.UVs[i],
+			Normal:    m.Normals[i],
+			Tangent:   m.Tangents[i],
+			Bitangent: Bitangent(m.Normals[i], m.Tangents[i]),
+		}
+	}
+	return verticies
+}
+
+// creates the openGL buffers for this mesh
+// missing normals and tangents are generated first
+func (m *MeshData) Upload() Object {
+	m.prepareUpload()
+
+	o := Object{}
+	o.fillBuffers(m)
+	return o
+}
+
+func (m *MeshData) prepareUpload() {
+	if err := m.Validate(); err != nil {
+		panic(err)
+	}
+	if m.Normals == nil {
+		m.CalcNormals(NormalOptions{Mode: FlatNormals})
+	}
+	if m.Tangents == nil {
+		m.CalcTangents()
+	}
+}
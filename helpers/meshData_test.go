@@ -0,0 +1,251 @@
+package helpers
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// a unit square in XY as two triangles that share the B-C edge
+func squareMesh() *MeshData {
+	a, b, c, d := mgl32.Vec3{0, 0, 0}, mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 1, 0}, mgl32.Vec3{1, 1, 0}
+	return &MeshData{
+		Positions: []mgl32.Vec3{a, b, c, c, b, d},
+		UVs:       []mgl32.Vec2{{0, 0}, {1, 0}, {0, 1}, {0, 1}, {1, 0}, {1, 1}},
+	}
+}
+
+func TestWeld(t *testing.T) {
+	m := squareMesh()
+	m.Weld()
+
+	wantPositions := []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {1, 1, 0}}
+	if !reflect.DeepEqual(m.Positions, wantPositions) {
+		t.Errorf("got positions %v, want %v", m.Positions, wantPositions)
+	}
+	if want := []uint32{0, 1, 2, 2, 1, 3}; !reflect.DeepEqual(m.Indices, want) {
+		t.Errorf("got indices %v, want %v", m.Indices, want)
+	}
+	if err := m.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	//the same position with a different UV is a seam and stays split
+	//but -0 and 0 are the same value
+	seam := squareMesh()
+	seam.UVs[3] = mgl32.Vec2{0.5, 1}
+	seam.Positions[4] = mgl32.Vec3{1, float32(math.Copysign(0, -1)), 0}
+	seam.Weld()
+	if want := []uint32{0, 1, 2, 3, 1, 4}; !reflect.DeepEqual(seam.Indices, want) {
+		t.Errorf("seam got indices %v, want %v", seam.Indices, want)
+	}
+
+	//an already indexed mesh is expanded and welded again
+	indexed := &MeshData{
+		Positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 0, 0}},
+		UVs:       make([]mgl32.Vec2, 4),
+		Indices:   []uint32{3, 1, 2},
+	}
+	indexed.Weld()
+	if want := []uint32{0, 1, 2}; len(indexed.Positions) != 3 || !reflect.DeepEqual(indexed.Indices, want) {
+		t.Errorf("indexed mesh got %d verticies and indices %v, want 3 and %v", len(indexed.Positions), indexed.Indices, want)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	triangle := func(x float32) *MeshData {
+		return &MeshData{
+			Positions: []mgl32.Vec3{{x, 0, 0}, {x + 1, 0, 0}, {x, 1, 0}},
+			UVs:       make([]mgl32.Vec2, 3),
+			Normals:   []mgl32.Vec3{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}},
+			Indices:   []uint32{0, 1, 2},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		first       *MeshData
+		second      *MeshData
+		wantIndices []uint32
+		wantNormals bool
+	}{
+		{
+			name:        "both indexed",
+			first:       triangle(0),
+			second:      triangle(5),
+			wantIndices: []uint32{0, 1, 2, 3, 4, 5},
+			wantNormals: true,
+		},
+		{
+			name:  "second reuses a vertex",
+			first: triangle(0),
+			second: func() *MeshData {
+				m := triangle(5)
+				m.Indices = []uint32{2, 1, 0, 0, 1, 2}
+				return m
+			}(),
+			wantIndices: []uint32{0, 1, 2, 5, 4, 3, 3, 4, 5},
+			wantNormals: true,
+		},
+		{
+			name:  "second unindexed",
+			first: triangle(0),
+			second: func() *MeshData {
+				m := triangle(5)
+				m.Indices = nil
+				return m
+			}(),
+			wantIndices: []uint32{0, 1, 2, 3, 4, 5},
+			wantNormals: true,
+		},
+		{
+			name: "first unindexed",
+			first: func() *MeshData {
+				m := triangle(0)
+				m.Indices = nil
+				return m
+			}(),
+			second:      triangle(5),
+			wantIndices: []uint32{0, 1, 2, 3, 4, 5},
+			wantNormals: true,
+		},
+		{
+			name: "only one has normals",
+			first: func() *MeshData {
+				m := triangle(0)
+				m.Normals = nil
+				return m
+			}(),
+			second:      triangle(5),
+			wantIndices: []uint32{0, 1, 2, 3, 4, 5},
+			wantNormals: false,
+		},
+		{
+			name:        "into an empty mesh",
+			first:       &MeshData{},
+			second:      triangle(5),
+			wantIndices: []uint32{0, 1, 2},
+			wantNormals: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wantPositions := append(append([]mgl32.Vec3(nil), tt.first.Positions...), tt.second.Positions...)
+			tt.first.Merge(tt.second)
+
+			if !reflect.DeepEqual(tt.first.Positions, wantPositions) {
+				t.Errorf("got positions %v, want %v", tt.first.Positions, wantPositions)
+			}
+			if !reflect.DeepEqual(tt.first.Indices, tt.wantIndices) {
+				t.Errorf("got indices %v, want %v", tt.first.Indices, tt.wantIndices)
+			}
+			if hasNormals := tt.first.Normals != nil; hasNormals != tt.wantNormals {
+				t.Errorf("got normals %v, want them kept %v", tt.first.Normals, tt.wantNormals)
+			}
+			if err := tt.first.Validate(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestTransform(t *testing.T) {
+	//a 45 degree slope, stretching it along X makes it shallower
+	//so the normal has to lean further towards +Y, not towards +X
+	m := &MeshData{
+		Positions: []mgl32.Vec3{{0, 0, 0}, {1, -1, 0}, {0, 0, 1}},
+		UVs:       make([]mgl32.Vec2, 3),
+		Normals:   []mgl32.Vec3{mgl32.Vec3{1, 1, 0}.Normalize()},
+	}
+	m.Normals = append(m.Normals, m.Normals[0], m.Normals[0])
+	m.Transform(mgl32.Scale3D(2, 1, 1))
+
+	if want := (mgl32.Vec3{2, -1, 0}); m.Positions[1] != want {
+		t.Errorf("got position %v, want %v", m.Positions[1], want)
+	}
+	want := mgl32.Vec3{0.5, 1, 0}.Normalize()
+	for i, n := range m.Normals {
+		if !n.ApproxEqualThreshold(want, 1e-6) {
+			t.Errorf("normal %d got %v, want %v", i, n, want)
+		}
+	}
+	//still perpendicular to the stretched surface
+	edge := m.Positions[1].Sub(m.Positions[0])
+	if d := m.Normals[0].Dot(edge); mgl32.Abs(d) > 1e-6 {
+		t.Errorf("normal isn't perpendicular to the surface, dot is %v", d)
+	}
+}
+
+func TestTransformMirrored(t *testing.T) {
+	m := &MeshData{
+		Positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+		UVs:       []mgl32.Vec2{{0, 0}, {1, 0}, {0, 1}},
+		Normals:   []mgl32.Vec3{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}},
+		Tangents:  []mgl32.Vec4{{1, 0, 0, 1}, {1, 0, 0, 1}, {1, 0, 0, 1}},
+		Indices:   []uint32{0, 1, 2},
+	}
+	m.Transform(mgl32.Scale3D(-1, 1, 1))
+
+	if want := []uint32{0, 2, 1}; !reflect.DeepEqual(m.Indices, want) {
+		t.Errorf("got indices %v, want the winding reversed %v", m.Indices, want)
+	}
+	if want := (mgl32.Vec4{-1, 0, 0, -1}); m.Tangents[0] != want {
+		t.Errorf("got tangent %v, want %v", m.Tangents[0], want)
+	}
+	//the face still points the way its normal says
+	p := m.Positions
+	face := p[m.Indices[1]].Sub(p[m.Indices[0]]).Cross(p[m.Indices[2]].Sub(p[m.Indices[0]]))
+	if face.Dot(m.Normals[0]) <= 0 {
+		t.Errorf("face %v points away from its normal %v", face, m.Normals[0])
+	}
+}
+
+func TestValidate(t *testing.T) {
+	nan := float32(math.NaN())
+	tests := []struct {
+		name string
+		mesh MeshData
+		err  string
+	}{
+		{
+			name: "valid",
+			mesh: MeshData{Positions: make([]mgl32.Vec3, 3), UVs: make([]mgl32.Vec2, 3), Indices: []uint32{0, 1, 2}},
+		},
+		{
+			name: "index out of range",
+			mesh: MeshData{Positions: make([]mgl32.Vec3, 3), UVs: make([]mgl32.Vec2, 3), Indices: []uint32{0, 1, 3}},
+			err:  "index 2 is 3 but the mesh only has 3 verticies",
+		},
+		{
+			name: "missing UVs",
+			mesh: MeshData{Positions: make([]mgl32.Vec3, 3), UVs: make([]mgl32.Vec2, 2)},
+			err:  "mesh has 3 positions but 2 UVs",
+		},
+		{
+			name: "missing normals",
+			mesh: MeshData{Positions: make([]mgl32.Vec3, 3), UVs: make([]mgl32.Vec2, 3), Normals: make([]mgl32.Vec3, 1)},
+			err:  "mesh has 3 positions but 1 normals",
+		},
+		{
+			name: "partial triangle",
+			mesh: MeshData{Positions: make([]mgl32.Vec3, 3), UVs: make([]mgl32.Vec2, 3), Indices: []uint32{0, 1, 2, 0}},
+			err:  "mesh has 4 verticies which isn't a whole number of triangles",
+		},
+		{
+			name: "NaN position",
+			mesh: MeshData{Positions: []mgl32.Vec3{{}, {0, nan, 0}, {}}, UVs: make([]mgl32.Vec2, 3)},
+			err:  "position 1 is NaN",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorText(tt.mesh.Validate()); got != tt.err {
+				t.Errorf("got error %q, want %q", got, tt.err)
+			}
+		})
+	}
+}
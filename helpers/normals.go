@@ -0,0 +1,131 @@
+package helpers
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+/*
+Vertex normal generation.
+This only works on go slices and doesn't touch openGL
+so it can be used (and tested) without a context
+*/
+
+type NormalMode int
+
+const (
+	// every corner gets the normal of its own triangle
+	FlatNormals NormalMode = iota
+	// corners at the same position share an averaged normal
+	SmoothNormals
+	// like SmoothNormals but only averages triangles whose faces
+	// are within CreaseAngle of each other so hard edges stay hard
+	CreasedNormals
+)
+
+type NormalWeighting int
+
+const (
+	// bigger triangles pull the average normal towards them more
+	AreaWeighted NormalWeighting = iota
+	// triangles are weighted by their angle at the corner, this stops
+	// a fan of thin triangles outweighing one big neighbour
+	AngleWeighted
+)
+
+type NormalOptions struct {
+	Mode        NormalMode
+	Weighting   NormalWeighting
+	CreaseAngle float32 //in degrees
+}
+
+// returns one normal for each corner of the triangles in indices
+// if indices is nil the positions are treated as an unindexed triangle list
+//
+// smoothing groups corners by position rather than index so meshes
+// that have already been split along UV seams still smooth across them
+func GenerateNormals(positions []mgl32.Vec3, indices []uint32, opts NormalOptions) []mgl32.Vec3 {
+	if indices == nil {
+		indices = make([]uint32, len(positions))
+		for i := range indices {
+			indices[i] = uint32(i)
+		}
+	}
+	triangleCount := len(indices) / 3
+
+	faceNormals := make([]mgl32.Vec3, triangleCount)
+	cornerWeights := make([]mgl32.Vec3, triangleCount*3)
+	for tri := 0; tri < triangleCount; tri++ {
+		p1 := positions[indices[tri*3]]
+		p2 := positions[indices[tri*3+1]]
+		p3 := positions[indices[tri*3+2]]
+
+		//the length of the cross product is twice the triangle's area
+		cross := p2.Sub(p1).Cross(p3.Sub(p1))
+		faceNormals[tri] = safeNormalize(cross)
+
+		corners := [3][3]mgl32.Vec3{{p1, p2, p3}, {p2, p3, p1}, {p3, p1, p2}}
+		for i, c := range corners {
+			switch opts.Weighting {
+			case AngleWeighted:
+				cornerWeights[tri*3+i] = faceNormals[tri].Mul(cornerAngle(c[0], c[1], c[2]))
+			default:
+				cornerWeights[tri*3+i] = cross
+			}
+		}
+	}
+
+	normals := make([]mgl32.Vec3, len(indices))
+	if opts.Mode == FlatNormals {
+		for corner := range normals {
+			normals[corner] = faceNormals[corner/3]
+		}
+		return normals
+	}
+
+	shared := make(map[mgl32.Vec3][]int)
+	for corner, index := range indices[:triangleCount*3] {
+		p := positions[index]
+		shared[p] = append(shared[p], corner)
+	}
+
+	minDot := float32(-1)
+	if opts.Mode == CreasedNormals {
+		minDot = Cos32Deg(opts.CreaseAngle)
+	}
+
+	for _, corners := range shared {
+		for _, corner := range corners {
+			face := faceNormals[corner/3]
+
+			var sum mgl32.Vec3
+			for _, other := range corners {
+				if face.Dot(faceNormals[other/3]) >= minDot || other/3 == corner/3 {
+					sum = sum.Add(cornerWeights[other])
+				}
+			}
+
+			normals[corner] = safeNormalize(sum)
+			if normals[corner] == (mgl32.Vec3{}) {
+				normals[corner] = face
+			}
+		}
+	}
+	return normals
+}
+
+// the angle at corner a of the triangle abc in radians
+func cornerAngle(a, b, c mgl32.Vec3) float32 {
+	ab := safeNormalize(b.Sub(a))
+	ac := safeNormalize(c.Sub(a))
+	return float32(math.Acos(float64(mgl32.Clamp(ab.Dot(ac), -1, 1))))
+}
+
+// normalizes v, leaving zero length vectors as zero instead of NaN
+func safeNormalize(v mgl32.Vec3) mgl32.Vec3 {
+	if v.Len() == 0 {
+		return mgl32.Vec3{}
+	}
+	return v.Normalize()
+}
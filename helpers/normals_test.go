@@ -0,0 +1,188 @@
+package helpers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// two triangles folded 90 degrees along the edge from (0,0,0) to (1,0,0)
+// the first faces +Z and the second +Y
+var foldPositions = []mgl32.Vec3{
+	{0, 0, 0}, {1, 0, 0}, {0, 1, 0},
+	{0, 0, 0}, {0, 0, 1}, {1, 0, 0},
+}
+
+func TestGenerateNormals(t *testing.T) {
+	up, side := mgl32.Vec3{0, 0, 1}, mgl32.Vec3{0, 1, 0}
+	between := mgl32.Vec3{0, 1, 1}.Normalize()
+	//the corners at (1,0,0) when the +Y triangle is stretched to 3 long
+	angled := mgl32.Vec3{0, float32(math.Atan(3)), math.Pi / 4}.Normalize()
+	//tilted 30 degrees from up towards +Y
+	tilted := mgl32.Vec3{0, Sin32Deg(30), Cos32Deg(30)}
+
+	tests := []struct {
+		name      string
+		positions []mgl32.Vec3
+		indices   []uint32
+		opts      NormalOptions
+		want      []mgl32.Vec3
+	}{
+		{
+			name:      "flat",
+			positions: foldPositions,
+			opts:      NormalOptions{Mode: FlatNormals},
+			want:      []mgl32.Vec3{up, up, up, side, side, side},
+		},
+		{
+			name:      "smooth",
+			positions: foldPositions,
+			opts:      NormalOptions{Mode: SmoothNormals},
+			want:      []mgl32.Vec3{between, between, up, between, side, between},
+		},
+		{
+			name:      "crease sharper than the angle",
+			positions: foldPositions,
+			opts:      NormalOptions{Mode: CreasedNormals, CreaseAngle: 60},
+			want:      []mgl32.Vec3{up, up, up, side, side, side},
+		},
+		{
+			name:      "crease within the angle",
+			positions: foldPositions,
+			opts:      NormalOptions{Mode: CreasedNormals, CreaseAngle: 120},
+			want:      []mgl32.Vec3{between, between, up, between, side, between},
+		},
+		{
+			//a third triangle at the origin 30 degrees from the first
+			//smooths with it but not with the one across the crease
+			name: "crease between soft and hard edges",
+			positions: append(append([]mgl32.Vec3(nil), foldPositions...),
+				mgl32.Vec3{0, 0, 0}, mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{0, -Cos32Deg(30), Sin32Deg(30)},
+			),
+			opts: NormalOptions{Mode: CreasedNormals, CreaseAngle: 45},
+			want: []mgl32.Vec3{
+				{0, Sin32Deg(15), Cos32Deg(15)}, up, up,
+				side, side, side,
+				{0, Sin32Deg(15), Cos32Deg(15)}, tilted, tilted,
+			},
+		},
+		{
+			//the +Y triangle is three times the area of the +Z one
+			name: "area weighted",
+			positions: []mgl32.Vec3{
+				{0, 0, 0}, {1, 0, 0}, {0, 1, 0},
+				{0, 0, 0}, {0, 0, 3}, {1, 0, 0},
+			},
+			opts: NormalOptions{Mode: SmoothNormals, Weighting: AreaWeighted},
+			want: []mgl32.Vec3{
+				mgl32.Vec3{0, 3, 1}.Normalize(), mgl32.Vec3{0, 3, 1}.Normalize(), up,
+				mgl32.Vec3{0, 3, 1}.Normalize(), side, mgl32.Vec3{0, 3, 1}.Normalize(),
+			},
+		},
+		{
+			//both triangles have a right angle at the origin so it's halfway
+			//at (1,0,0) the corners are 45 degrees and atan(3)
+			name: "angle weighted",
+			positions: []mgl32.Vec3{
+				{0, 0, 0}, {1, 0, 0}, {0, 1, 0},
+				{0, 0, 0}, {0, 0, 3}, {1, 0, 0},
+			},
+			opts: NormalOptions{Mode: SmoothNormals, Weighting: AngleWeighted},
+			want: []mgl32.Vec3{
+				between, angled, up,
+				between, side, angled,
+			},
+		},
+		{
+			name: "degenerate triangle",
+			positions: []mgl32.Vec3{
+				{0, 0, 0}, {1, 0, 0}, {0, 1, 0},
+				{0, 0, 0}, {1, 0, 0}, {2, 0, 0},
+			},
+			opts: NormalOptions{Mode: SmoothNormals},
+			want: []mgl32.Vec3{up, up, up, up, up, {}},
+		},
+		{
+			name: "degenerate triangle flat",
+			positions: []mgl32.Vec3{
+				{0, 0, 0}, {1, 0, 0}, {0, 1, 0},
+				{0, 0, 0}, {1, 0, 0}, {2, 0, 0},
+			},
+			opts: NormalOptions{Mode: FlatNormals},
+			want: []mgl32.Vec3{up, up, up, {}, {}, {}},
+		},
+		{
+			//the normals are per corner so a vertex shared by index
+			//still gets a different normal on each side of the crease
+			name:      "shared verticies across a crease",
+			positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+			indices:   []uint32{0, 1, 2, 0, 3, 1},
+			opts:      NormalOptions{Mode: CreasedNormals, CreaseAngle: 60},
+			want:      []mgl32.Vec3{up, up, up, side, side, side},
+		},
+		{
+			name:      "shared verticies smoothed",
+			positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+			indices:   []uint32{0, 1, 2, 0, 3, 1},
+			opts:      NormalOptions{Mode: SmoothNormals},
+			want:      []mgl32.Vec3{between, between, up, between, side, between},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GenerateNormals(tt.positions, tt.indices, tt.opts)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d normals, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if !got[i].ApproxEqualThreshold(tt.want[i], 1e-5) {
+					t.Errorf("corner %d got %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// a quad facing +Z, the mirrored one has its U running the other way
+// like the second half of a symmetrical model sharing one texture
+func TestGenerateTangentsHandedness(t *testing.T) {
+	positions := []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}}
+	normals := []mgl32.Vec3{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}, {0, 0, 1}}
+	indices := []uint32{0, 1, 2, 0, 2, 3}
+
+	tests := []struct {
+		name    string
+		uvs     []mgl32.Vec2
+		want    mgl32.Vec4
+		wantBit mgl32.Vec3
+	}{
+		{
+			name:    "normal",
+			uvs:     []mgl32.Vec2{{0, 0}, {1, 0}, {1, 1}, {0, 1}},
+			want:    mgl32.Vec4{1, 0, 0, 1},
+			wantBit: mgl32.Vec3{0, 1, 0},
+		},
+		{
+			name:    "mirrored",
+			uvs:     []mgl32.Vec2{{1, 0}, {0, 0}, {0, 1}, {1, 1}},
+			want:    mgl32.Vec4{-1, 0, 0, -1},
+			wantBit: mgl32.Vec3{0, 1, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tangents := GenerateTangents(positions, tt.uvs, normals, indices)
+			for i, tangent := range tangents {
+				if !tangent.ApproxEqualThreshold(tt.want, 1e-5) {
+					t.Errorf("vertex %d got tangent %v, want %v", i, tangent, tt.want)
+				}
+				if b := Bitangent(normals[i], tangent); !b.ApproxEqualThreshold(tt.wantBit, 1e-5) {
+					t.Errorf("vertex %d got bitangent %v, want %v", i, b, tt.wantBit)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,643 @@
+package helpers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// a material parsed from a wavefront .mtl file
+// texture maps are stored as paths resolved relative to the .mtl file
+type Material struct {
+	Name string
+
+	Ambient   mgl32.Vec3 // Ka
+	Diffuse   mgl32.Vec3 // Kd
+	Specular  mgl32.Vec3 // Ks
+	Emissive  mgl32.Vec3 // Ke
+	Shininess float32    // Ns
+	Opacity   float32    // d (or 1-Tr)
+
+	AmbientMap  string // map_Ka
+	DiffuseMap  string // map_Kd
+	SpecularMap string // map_Ks
+	NormalMap   string // norm
+	BumpMap     string // map_Bump or bump
+	OpacityMap  string // map_d
+}
+
+func newMaterial(name string) *Material {
+	m := Material{
+		Name:      name,
+		Diffuse:   mgl32.Vec3{1, 1, 1},
+		Shininess: 32,
+		Opacity:   1,
+	}
+	return &m
+}
+
+// one drawable piece of a model
+// a new part is started for every group, object or material change
+type ModelPart struct {
+	Name     string
+	Material *Material
+	Object   Object
+}
+
+type Model struct {
+	Parts     []ModelPart
+	Materials map[string]*Material
+}
+
+func (m Model) Draw(shader *Shader, drawMatrix mgl32.Mat4) {
+	for _, p := range m.Parts {
+		p.Object.Draw(shader, drawMatrix)
+	}
+}
+
+func (m *Model) Delete() {
+	for i := range m.Parts {
+		m.Parts[i].Object.Delete()
+	}
+}
+
+type OBJOptions struct {
+	// how normals are generated for faces that don't specify them
+	Normals NormalOptions
+	// ignore any normals in the file and generate all of them
+	RecalculateNormals bool
+}
+
+var DefaultOBJOptions = OBJOptions{
+	Normals: NormalOptions{
+		Mode:        CreasedNormals,
+		Weighting:   AngleWeighted,
+		CreaseAngle: 60,
+	},
+}
+
+// loads a wavefront .obj file and any .mtl files it references
+// malformed lines are reported as errors with the file and line number
+func LoadOBJ(path string) (*Model, error) {
+	return LoadOBJWithOptions(path, DefaultOBJOptions)
+}
+
+func LoadOBJWithOptions(path string, opts OBJOptions) (*Model, error) {
+	parts, materials, err := LoadOBJMeshes(path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	model := Model{
+		Materials: materials,
+	}
+	for _, p := range parts {
+		model.Parts = append(model.Parts, ModelPart{
+			Name:     p.Name,
+			Material: p.Material,
+			Object:   p.Mesh.Upload(),
+		})
+	}
+	return &model, nil
+}
+
+// the CPU side of a ModelPart
+type MeshPart struct {
+	Name     string
+	Material *Material
+	Mesh     *MeshData
+}
+
+// parses an obj file into welded meshes without uploading them
+func LoadOBJMeshes(path string, opts OBJOptions) ([]MeshPart, map[string]*Material, error) {
+	data, err := parseOBJFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var parts []MeshPart
+	for _, g := range data.groups {
+		if g.mesh.VertexCount() == 0 {
+			continue
+		}
+		g.fillNormals(opts)
+		g.mesh.Weld()
+
+		parts = append(parts, MeshPart{
+			Name:     g.name,
+			Material: g.material,
+			Mesh:     &g.mesh,
+		})
+	}
+	return parts, data.materials, nil
+}
+
+// the GL independent result of parsing an obj file
+type objData struct {
+	groups    []*objGroup
+	materials map[string]*Material
+}
+
+type objGroup struct {
+	name      string
+	material  *Material
+	mesh      MeshData //an unindexed triangle list until it is welded
+	hasNormal []bool   //false for corners without a normal in the file
+}
+
+// generates the normals that are missing (or all of them if requested)
+func (g *objGroup) fillNormals(opts OBJOptions) {
+	missing := opts.RecalculateNormals
+	for _, has := range g.hasNormal {
+		missing = missing || !has
+	}
+	if !missing {
+		return
+	}
+
+	for i, n := range GenerateNormals(g.mesh.Positions, nil, opts.Normals) {
+		if opts.RecalculateNormals || !g.hasNormal[i] {
+			g.mesh.Normals[i] = n
+		}
+	}
+}
+
+// an index triple from a face statement, -1 means not present
+type objCorner struct {
+	v, vt, vn int
+}
+
+type objParser struct {
+	path string
+	line int
+
+	positions []mgl32.Vec3
+	uvs       []mgl32.Vec2
+	normals   []mgl32.Vec3
+
+	data      objData
+	current   *objGroup
+	groupName string
+	material  *Material
+	skipped   map[string]bool //unknown statements that have been warned about
+}
+
+func parseOBJFile(path string) (*objData, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseOBJ(file, path)
+}
+
+// path is used for error messages and to resolve mtllib statements
+func parseOBJ(r io.Reader, path string) (*objData, error) {
+	p := objParser{
+		path:      path,
+		groupName: "default",
+		skipped:   make(map[string]bool),
+	}
+	p.data.materials = make(map[string]*Material)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		p.line++
+		fields := strings.Fields(stripComment(scanner.Text()))
+		if len(fields) == 0 {
+			continue
+		}
+		if err := p.parseLine(fields); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &p.data, nil
+}
+
+func (p *objParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%s:%d: %s", p.path, p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *objParser) parseLine(fields []string) error {
+	args := fields[1:]
+
+	switch fields[0] {
+	case "v":
+		if len(args) < 3 || len(args) > 4 {
+			return p.errorf("vertex needs 3 or 4 components, got %d", len(args))
+		}
+		v, err := p.parseFloats(args[:3])
+		if err != nil {
+			return err
+		}
+		p.positions = append(p.positions, mgl32.Vec3{v[0], v[1], v[2]})
+	case "vt":
+		if len(args) < 1 || len(args) > 3 {
+			return p.errorf("texture coordinate needs 1 to 3 components, got %d", len(args))
+		}
+		v, err := p.parseFloats(args)
+		if err != nil {
+			return err
+		}
+		uv := mgl32.Vec2{v[0], 0}
+		if len(v) > 1 {
+			uv[1] = v[1]
+		}
+		p.uvs = append(p.uvs, uv)
+	case "vn":
+		if len(args) != 3 {
+			return p.errorf("normal needs 3 components, got %d", len(args))
+		}
+		v, err := p.parseFloats(args)
+		if err != nil {
+			return err
+		}
+		p.normals = append(p.normals, mgl32.Vec3{v[0], v[1], v[2]})
+	case "f":
+		return p.parseFace(args)
+	case "g", "o":
+		name := strings.Join(args, " ")
+		if name == "" {
+			name = "default"
+		}
+		p.groupName = name
+		p.current = nil
+	case "usemtl":
+		if len(args) == 0 {
+			return p.errorf("usemtl needs a material name")
+		}
+		name := strings.Join(args, " ")
+		m, ok := p.data.materials[name]
+		if !ok {
+			//a missing material shouldn't stop the model loading, draw it with the defaults
+			warnSkipped(p.skipped, p.path, "usemtl "+name)
+			m = newMaterial(name)
+			p.data.materials[name] = m
+		}
+		p.material = m
+		p.current = nil
+	case "mtllib":
+		if len(args) == 0 {
+			return p.errorf("mtllib needs a file name")
+		}
+		for _, a := range args {
+			mtlPath := filepath.Join(filepath.Dir(p.path), a)
+			materials, err := parseMTLFile(mtlPath)
+			if err != nil {
+				return err
+			}
+			for name, m := range materials {
+				p.data.materials[name] = m
+			}
+		}
+	case "s", "l", "p", "vp", "cstype", "deg", "bmat", "step", "curv", "curv2", "surf", "parm", "trim", "hole",
+		"scrv", "sp", "end", "con", "mg", "lod", "bevel", "c_interp", "d_interp", "ctech", "stech",
+		"shadow_obj", "trace_obj", "maplib", "usemap":
+		//smoothing and merging groups, lines, points, free-form geometry and render settings aren't drawn
+	default:
+		warnSkipped(p.skipped, p.path, fields[0])
+	}
+	return nil
+}
+
+func (p *objParser) parseFloats(args []string) ([]float32, error) {
+	values := make([]float32, len(args))
+	for i, a := range args {
+		f, err := strconv.ParseFloat(a, 32)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", a)
+		}
+		values[i] = float32(f)
+	}
+	return values, nil
+}
+
+// resolves a 1 based (or negative relative) obj index into a 0 based one
+func (p *objParser) resolveIndex(s string, count int, kind string) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, p.errorf("invalid %s index %q", kind, s)
+	}
+	if i < 0 {
+		i += count
+	} else {
+		i--
+	}
+	if i < 0 || i >= count {
+		return 0, p.errorf("%s index %s out of range (have %d)", kind, s, count)
+	}
+	return i, nil
+}
+
+func (p *objParser) parseCorner(s string) (objCorner, error) {
+	c := objCorner{-1, -1, -1}
+	parts := strings.Split(s, "/")
+	if len(parts) > 3 || parts[0] == "" {
+		return c, p.errorf("malformed face vertex %q", s)
+	}
+
+	var err error
+	if c.v, err = p.resolveIndex(parts[0], len(p.positions), "vertex"); err != nil {
+		return c, err
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		if c.vt, err = p.resolveIndex(parts[1], len(p.uvs), "texture coordinate"); err != nil {
+			return c, err
+		}
+	}
+	if len(parts) > 2 && parts[2] != "" {
+		if c.vn, err = p.resolveIndex(parts[2], len(p.normals), "normal"); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+func (p *objParser) parseFace(args []string) error {
+	if len(args) < 3 {
+		return p.errorf("face needs at least 3 vertices, got %d", len(args))
+	}
+
+	corners := make([]objCorner, len(args))
+	polygon := make([]mgl32.Vec3, len(args))
+	for i, a := range args {
+		c, err := p.parseCorner(a)
+		if err != nil {
+			return err
+		}
+		corners[i] = c
+		polygon[i] = p.positions[c.v]
+	}
+
+	g := p.group()
+	for _, tri := range TriangulatePolygon(polygon) {
+		for _, i := range tri {
+			corner := corners[i]
+			pos := p.positions[corner.v]
+			uv := mgl32.Vec2{}
+			if corner.vt >= 0 {
+				uv = p.uvs[corner.vt]
+			}
+			normal := mgl32.Vec3{} //filled in by fillNormals
+			if corner.vn >= 0 {
+				normal = p.normals[corner.vn]
+			}
+
+			g.mesh.Positions = append(g.mesh.Positions, pos)
+			g.mesh.UVs = append(g.mesh.UVs, uv)
+			g.mesh.Normals = append(g.mesh.Normals, normal)
+			g.hasNormal = append(g.hasNormal, corner.vn >= 0)
+		}
+	}
+	return nil
+}
+
+// returns the group faces are currently being added to
+// creating it if the group or material has changed
+func (p *objParser) group() *objGroup {
+	if p.current == nil {
+		p.current = &objGroup{
+			name:     p.groupName,
+			material: p.material,
+		}
+		p.data.groups = append(p.data.groups, p.current)
+	}
+	return p.current
+}
+
+// splits a simple polygon into triangles using ear clipping
+// returns index triples into polygon with the same winding as the input
+func TriangulatePolygon(polygon []mgl32.Vec3) [][3]int {
+	n := len(polygon)
+	if n < 3 {
+		return nil
+	}
+	if n == 3 {
+		return [][3]int{{0, 1, 2}}
+	}
+
+	//newell's method gives a normal that works for concave polygons
+	var normal mgl32.Vec3
+	for i := 0; i < n; i++ {
+		cur, next := polygon[i], polygon[(i+1)%n]
+		normal[0] += (cur.Y() - next.Y()) * (cur.Z() + next.Z())
+		normal[1] += (cur.Z() - next.Z()) * (cur.X() + next.X())
+		normal[2] += (cur.X() - next.X()) * (cur.Y() + next.Y())
+	}
+
+	remaining := make([]int, n)
+	for i := range remaining {
+		remaining[i] = i
+	}
+
+	triangles := make([][3]int, 0, n-2)
+	for len(remaining) > 3 {
+		earFound := false
+		for i := range remaining {
+			prev := remaining[(i+len(remaining)-1)%len(remaining)]
+			cur := remaining[i]
+			next := remaining[(i+1)%len(remaining)]
+
+			if !isEar(polygon, remaining, prev, cur, next, normal) {
+				continue
+			}
+			triangles = append(triangles, [3]int{prev, cur, next})
+			remaining = append(remaining[:i], remaining[i+1:]...)
+			earFound = true
+			break
+		}
+
+		//degenerate or self intersecting polygons fall back to a fan
+		if !earFound {
+			for i := 1; i < len(remaining)-1; i++ {
+				triangles = append(triangles, [3]int{remaining[0], remaining[i], remaining[i+1]})
+			}
+			return triangles
+		}
+	}
+	return append(triangles, [3]int{remaining[0], remaining[1], remaining[2]})
+}
+
+func isEar(polygon []mgl32.Vec3, remaining []int, prev, cur, next int, normal mgl32.Vec3) bool {
+	a, b, c := polygon[prev], polygon[cur], polygon[next]
+
+	//reflex corners point away from the polygon normal
+	if b.Sub(a).Cross(c.Sub(b)).Dot(normal) <= 0 {
+		return false
+	}
+
+	for _, i := range remaining {
+		if i == prev || i == cur || i == next {
+			continue
+		}
+		if pointInTriangle(polygon[i], a, b, c, normal) {
+			return false
+		}
+	}
+	return true
+}
+
+func pointInTriangle(p, a, b, c, normal mgl32.Vec3) bool {
+	return b.Sub(a).Cross(p.Sub(a)).Dot(normal) >= 0 &&
+		c.Sub(b).Cross(p.Sub(b)).Dot(normal) >= 0 &&
+		a.Sub(c).Cross(p.Sub(c)).Dot(normal) >= 0
+}
+
+func parseMTLFile(path string) (map[string]*Material, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseMTL(file, path)
+}
+
+func parseMTL(r io.Reader, path string) (map[string]*Material, error) {
+	materials := make(map[string]*Material)
+	var current *Material
+	skipped := make(map[string]bool)
+
+	errorf := func(line int, format string, args ...any) error {
+		return fmt.Errorf("%s:%d: %s", path, line, fmt.Sprintf(format, args...))
+	}
+	//spectral and CIE XYZ colours are left as they were
+	parseColor := func(line int, old mgl32.Vec3, fields []string) (mgl32.Vec3, error) {
+		args := fields[1:]
+		if len(args) > 0 && (args[0] == "spectral" || args[0] == "xyz") {
+			warnSkipped(skipped, path, fields[0]+" "+args[0])
+			return old, nil
+		}
+		if len(args) != 3 && len(args) != 1 {
+			return mgl32.Vec3{}, errorf(line, "colour needs 1 or 3 components, got %d", len(args))
+		}
+		var c mgl32.Vec3
+		for i := range c {
+			a := args[0]
+			if len(args) == 3 {
+				a = args[i]
+			}
+			f, err := strconv.ParseFloat(a, 32)
+			if err != nil {
+				return c, errorf(line, "invalid number %q", a)
+			}
+			c[i] = float32(f)
+		}
+		return c, nil
+	}
+	parseScalar := func(line int, args []string) (float32, error) {
+		if len(args) != 1 {
+			return 0, errorf(line, "expected 1 value, got %d", len(args))
+		}
+		f, err := strconv.ParseFloat(args[0], 32)
+		if err != nil {
+			return 0, errorf(line, "invalid number %q", args[0])
+		}
+		return float32(f), nil
+	}
+	//map statements can have options before the file name e.g. "map_Bump -bm 0.5 bump.png"
+	parseMap := func(line int, args []string) (string, error) {
+		if len(args) == 0 {
+			return "", errorf(line, "texture map needs a file name")
+		}
+		return filepath.Join(filepath.Dir(path), args[len(args)-1]), nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(stripComment(scanner.Text()))
+		if len(fields) == 0 {
+			continue
+		}
+		args := fields[1:]
+
+		if fields[0] == "newmtl" {
+			if len(args) == 0 {
+				return nil, errorf(line, "newmtl needs a material name")
+			}
+			current = newMaterial(strings.Join(args, " "))
+			materials[current.Name] = current
+			continue
+		}
+		if current == nil {
+			return nil, errorf(line, "%q before any newmtl statement", fields[0])
+		}
+
+		var err error
+		switch fields[0] {
+		case "Ka":
+			current.Ambient, err = parseColor(line, current.Ambient, fields)
+		case "Kd":
+			current.Diffuse, err = parseColor(line, current.Diffuse, fields)
+		case "Ks":
+			current.Specular, err = parseColor(line, current.Specular, fields)
+		case "Ke":
+			current.Emissive, err = parseColor(line, current.Emissive, fields)
+		case "Ns":
+			current.Shininess, err = parseScalar(line, args)
+		case "d":
+			if len(args) == 2 && args[0] == "-halo" {
+				args = args[1:]
+			}
+			current.Opacity, err = parseScalar(line, args)
+		case "Tr":
+			var tr float32
+			tr, err = parseScalar(line, args)
+			current.Opacity = 1 - tr
+		case "map_Ka":
+			current.AmbientMap, err = parseMap(line, args)
+		case "map_Kd":
+			current.DiffuseMap, err = parseMap(line, args)
+		case "map_Ks":
+			current.SpecularMap, err = parseMap(line, args)
+		case "norm", "map_Kn":
+			current.NormalMap, err = parseMap(line, args)
+		case "map_Bump", "map_bump", "bump":
+			current.BumpMap, err = parseMap(line, args)
+		case "map_d":
+			current.OpacityMap, err = parseMap(line, args)
+		case "illum", "Ni", "Tf", "map_Ns", "map_Ke", "disp", "decal", "refl", "sharpness",
+			"Pr", "Pm", "Ps", "Pc", "Pcr", "aniso", "anisor", "map_Pr", "map_Pm", "map_Ps", "map_aat":
+			//not used by any of our shaders, including the PBR extension
+		default:
+			warnSkipped(skipped, path, fields[0])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return materials, nil
+}
+
+// statements we don't know are skipped, warning once per file for each
+func warnSkipped(skipped map[string]bool, path, statement string) {
+	if skipped[statement] {
+		return
+	}
+	skipped[statement] = true
+	fmt.Printf("%s: skipping unsupported statement %q\n", path, statement)
+}
+
+func stripComment(line string) string {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
@@ -0,0 +1,223 @@
+package helpers
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestParseOBJFaces(t *testing.T) {
+	tests := []struct {
+		name      string
+		obj       string
+		positions []mgl32.Vec3
+		uvs       []mgl32.Vec2
+		normals   []mgl32.Vec3
+		hasNormal []bool
+		err       string
+	}{
+		{
+			name:      "positions only",
+			obj:       "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3",
+			positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+			uvs:       []mgl32.Vec2{{}, {}, {}},
+			normals:   []mgl32.Vec3{{}, {}, {}},
+			hasNormal: []bool{false, false, false},
+		},
+		{
+			//negative indices count back from the last vertex read so far
+			name:      "relative indices",
+			obj:       "v 0 0 0\nv 1 0 0\nv 0 1 0\nv 9 9 9\nf -4 -3 -2\nv 0 0 1\nf -1 -3 -2",
+			positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 0, 1}, {0, 1, 0}, {9, 9, 9}},
+			uvs:       []mgl32.Vec2{{}, {}, {}, {}, {}, {}},
+			normals:   []mgl32.Vec3{{}, {}, {}, {}, {}, {}},
+			hasNormal: []bool{false, false, false, false, false, false},
+		},
+		{
+			name:      "position and texture coordinate",
+			obj:       "v 0 0 0\nv 1 0 0\nv 0 1 0\nvt 0 0\nvt 1 0.5\nf 1/1 2/2 3/-1",
+			positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+			uvs:       []mgl32.Vec2{{0, 0}, {1, 0.5}, {1, 0.5}},
+			normals:   []mgl32.Vec3{{}, {}, {}},
+			hasNormal: []bool{false, false, false},
+		},
+		{
+			name:      "position and normal",
+			obj:       "v 0 0 0\nv 1 0 0\nv 0 1 0\nvn 0 0 -1\nf 1//1 2//1 3//-1",
+			positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+			uvs:       []mgl32.Vec2{{}, {}, {}},
+			normals:   []mgl32.Vec3{{0, 0, -1}, {0, 0, -1}, {0, 0, -1}},
+			hasNormal: []bool{true, true, true},
+		},
+		{
+			name:      "all three",
+			obj:       "v 0 0 0\nv 1 0 0\nv 0 1 0\nvt 0.25\nvn 0 0 1\nf 1/1/1 2/1/1 3/1",
+			positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+			uvs:       []mgl32.Vec2{{0.25, 0}, {0.25, 0}, {0.25, 0}},
+			normals:   []mgl32.Vec3{{0, 0, 1}, {0, 0, 1}, {}},
+			hasNormal: []bool{true, true, false},
+		},
+		{
+			name: "relative index before the vertex",
+			obj:  "v 0 0 0\nv 1 0 0\nf 1 2 -3",
+			err:  "test.obj:3: vertex index -3 out of range (have 2)",
+		},
+		{
+			name: "missing normal",
+			obj:  "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1//1 2//1 3//1",
+			err:  "test.obj:4: normal index 1 out of range (have 0)",
+		},
+		{
+			name: "too many slashes",
+			obj:  "v 0 0 0\nf 1/1/1/1 1 1",
+			err:  `test.obj:2: malformed face vertex "1/1/1/1"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseOBJ(strings.NewReader(tt.obj), "test.obj")
+			if got := errorText(err); got != tt.err {
+				t.Fatalf("got error %q, want %q", got, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if len(data.groups) != 1 {
+				t.Fatalf("got %d groups, want 1", len(data.groups))
+			}
+			g := data.groups[0]
+			if !reflect.DeepEqual(g.mesh.Positions, tt.positions) {
+				t.Errorf("got positions %v, want %v", g.mesh.Positions, tt.positions)
+			}
+			if !reflect.DeepEqual(g.mesh.UVs, tt.uvs) {
+				t.Errorf("got UVs %v, want %v", g.mesh.UVs, tt.uvs)
+			}
+			if !reflect.DeepEqual(g.mesh.Normals, tt.normals) {
+				t.Errorf("got normals %v, want %v", g.mesh.Normals, tt.normals)
+			}
+			if !reflect.DeepEqual(g.hasNormal, tt.hasNormal) {
+				t.Errorf("got hasNormal %v, want %v", g.hasNormal, tt.hasNormal)
+			}
+		})
+	}
+}
+
+func TestTriangulatePolygon(t *testing.T) {
+	tests := []struct {
+		name    string
+		polygon []mgl32.Vec3
+		want    [][3]int
+	}{
+		{
+			name:    "triangle",
+			polygon: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+			want:    [][3]int{{0, 1, 2}},
+		},
+		{
+			name:    "convex quad",
+			polygon: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}},
+			want:    [][3]int{{3, 0, 1}, {1, 2, 3}},
+		},
+		{
+			//corner 3 points inwards so a fan from corner 0 would cover it
+			name:    "concave quad",
+			polygon: []mgl32.Vec3{{0, 0, 0}, {2, 1, 0}, {0, 2, 0}, {1, 1, 0}},
+			want:    [][3]int{{3, 0, 1}, {1, 2, 3}},
+		},
+		{
+			//the same dart wound the other way starting at the inward corner
+			name:    "concave quad clockwise",
+			polygon: []mgl32.Vec3{{1, 1, 0}, {0, 2, 0}, {2, 1, 0}, {0, 0, 0}},
+			want:    [][3]int{{0, 1, 2}, {0, 2, 3}},
+		},
+		{
+			name:    "concave quad facing sideways",
+			polygon: []mgl32.Vec3{{0, 0, 0}, {0, 2, 1}, {0, 0, 2}, {0, 1, 1}},
+			want:    [][3]int{{3, 0, 1}, {1, 2, 3}},
+		},
+		{
+			name:    "too few corners",
+			polygon: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}},
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TriangulatePolygon(tt.polygon); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadOBJMaterials(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "model.mtl"), `newmtl red
+Kd 1 0 0
+map_Kd -bm 1 textures/red.png
+`)
+	writeFile(t, filepath.Join(dir, "model.obj"), `mtllib model.mtl
+v 0 0 0
+v 1 0 0
+v 0 1 0
+f 1 2 3
+usemtl red
+f 1 2 3
+usemtl missing
+f 1 2 3
+usemtl red
+f 1 2 3
+`)
+
+	parts, materials, err := LoadOBJMeshes(filepath.Join(dir, "model.obj"), DefaultOBJOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 4 {
+		t.Fatalf("got %d parts, want 4", len(parts))
+	}
+
+	red := materials["red"]
+	if red == nil {
+		t.Fatal("red wasn't loaded from the mtllib")
+	}
+	if red.Diffuse != (mgl32.Vec3{1, 0, 0}) {
+		t.Errorf("red has diffuse %v", red.Diffuse)
+	}
+	if want := filepath.Join(dir, "textures", "red.png"); red.DiffuseMap != want {
+		t.Errorf("red has diffuse map %q, want %q", red.DiffuseMap, want)
+	}
+
+	if parts[0].Material != nil {
+		t.Errorf("faces before any usemtl got material %q", parts[0].Material.Name)
+	}
+	if parts[1].Material != red || parts[3].Material != red {
+		t.Error("faces after usemtl red didn't get red")
+	}
+	//an unknown material is warned about and drawn with the defaults
+	missing := parts[2].Material
+	if missing == nil || missing.Name != "missing" || *missing != *newMaterial("missing") {
+		t.Errorf("faces after an unknown usemtl got %+v, want the default material", missing)
+	}
+}
+
+func TestLoadOBJMissingMTL(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "model.obj"), "mtllib missing.mtl\n")
+
+	if _, _, err := LoadOBJMeshes(filepath.Join(dir, "model.obj"), DefaultOBJOptions); err == nil {
+		t.Error("loaded with a missing mtllib")
+	}
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,350 @@
+package helpers
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+/*
+Parametric shapes that are generated rather than typed out.
+All of them are centred on the origin, use Y as up and
+wind their triangles counter-clockwise when seen from outside
+*/
+
+// collects indexed verticies for the shapes below
+type meshBuilder struct {
+	mesh MeshData
+}
+
+func (b *meshBuilder) addVertex(pos mgl32.Vec3, uv mgl32.Vec2, normal mgl32.Vec3) uint32 {
+	index := uint32(b.mesh.VertexCount())
+	b.mesh.Positions = append(b.mesh.Positions, pos)
+	b.mesh.UVs = append(b.mesh.UVs, uv)
+	b.mesh.Normals = append(b.mesh.Normals, normal)
+	return index
+}
+
+// adds a triangle unless two of its corners share a position
+// which happens at the poles of spheres and the tip of cones
+func (b *meshBuilder) addTriangle(i1, i2, i3 uint32) {
+	p1, p2, p3 := b.mesh.Positions[i1], b.mesh.Positions[i2], b.mesh.Positions[i3]
+	const epsilon = 1e-5
+	if p1.Sub(p2).Len() < epsilon || p2.Sub(p3).Len() < epsilon || p3.Sub(p1).Len() < epsilon {
+		return
+	}
+	b.mesh.Indices = append(b.mesh.Indices, i1, i2, i3)
+}
+
+// adds a (rows+1)*(cols+1) grid of verticies and triangulates it
+// the surface faces outwards when the columns run anticlockwise (X towards Z)
+// and the rows run downwards, flip reverses that
+func (b *meshBuilder) addGrid(rows, cols int, flip bool, vertex func(r, c int) (pos mgl32.Vec3, uv mgl32.Vec2, normal mgl32.Vec3)) {
+	first := uint32(b.mesh.VertexCount())
+	for r := 0; r <= rows; r++ {
+		for c := 0; c <= cols; c++ {
+			b.addVertex(vertex(r, c))
+		}
+	}
+
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			topLeft := first + uint32(r*(cols+1)+c)
+			topRight := topLeft + 1
+			bottomLeft := topLeft + uint32(cols+1)
+			bottomRight := bottomLeft + 1
+
+			if flip {
+				b.addTriangle(topLeft, bottomLeft, topRight)
+				b.addTriangle(topRight, bottomLeft, bottomRight)
+			} else {
+				b.addTriangle(topLeft, topRight, bottomLeft)
+				b.addTriangle(topRight, bottomRight, bottomLeft)
+			}
+		}
+	}
+}
+
+// adds a flat disc facing up (or down if flip is set) at height y
+func (b *meshBuilder) addDisc(radius, y float32, segments int, flip bool) {
+	normal := mgl32.Vec3{0, 1, 0}
+	if flip {
+		normal = normal.Mul(-1)
+	}
+
+	b.addGrid(1, segments, flip, func(r, c int) (mgl32.Vec3, mgl32.Vec2, mgl32.Vec3) {
+		angle := 2 * math.Pi * float32(c) / float32(segments)
+		dir := mgl32.Vec3{Cos32(angle), 0, Sin32(angle)}
+
+		pos := dir.Mul(radius * float32(r)).Add(mgl32.Vec3{0, y, 0})
+		uv := mgl32.Vec2{0.5 + 0.5*dir.X()*float32(r), 0.5 + 0.5*dir.Z()*float32(r)}
+		return pos, uv, normal
+	})
+}
+
+func (b *meshBuilder) result() *MeshData {
+	if b.mesh.Indices == nil {
+		b.mesh.Indices = []uint32{}
+	}
+	return &b.mesh
+}
+
+// a sphere made of segments around the Y axis and rings from pole to pole
+func UVSphere(radius float32, segments, rings int) Object {
+	return UVSphereMesh(radius, segments, rings).Upload()
+}
+
+func UVSphereMesh(radius float32, segments, rings int) *MeshData {
+	segments = max(segments, 3)
+	rings = max(rings, 2)
+
+	b := meshBuilder{}
+	b.addGrid(rings, segments, false, func(r, c int) (mgl32.Vec3, mgl32.Vec2, mgl32.Vec3) {
+		normal := sphereDirection(math.Pi*float32(r)/float32(rings), 2*math.Pi*float32(c)/float32(segments))
+		uv := mgl32.Vec2{float32(c) / float32(segments), 1 - float32(r)/float32(rings)}
+		return normal.Mul(radius), uv, normal
+	})
+	return b.result()
+}
+
+// the unit vector at polar angle phi (0 being +Y) and azimuth theta
+func sphereDirection(phi, theta float32) mgl32.Vec3 {
+	return mgl32.Vec3{
+		Sin32(phi) * Cos32(theta),
+		Cos32(phi),
+		Sin32(phi) * Sin32(theta),
+	}
+}
+
+// a sphere made by repeatedly subdividing an icosahedron
+// which spreads the triangles more evenly than a UVSphere
+func Icosphere(radius float32, subdivisions int) Object {
+	return IcosphereMesh(radius, subdivisions).Upload()
+}
+
+func IcosphereMesh(radius float32, subdivisions int) *MeshData {
+	subdivisions = max(subdivisions, 0)
+
+	t := float32((1 + math.Sqrt(5)) / 2)
+	points := []mgl32.Vec3{
+		{-1, t, 0}, {1, t, 0}, {-1, -t, 0}, {1, -t, 0},
+		{0, -1, t}, {0, 1, t}, {0, -1, -t}, {0, 1, -t},
+		{t, 0, -1}, {t, 0, 1}, {-t, 0, -1}, {-t, 0, 1},
+	}
+	for i := range points {
+		points[i] = points[i].Normalize()
+	}
+	faces := [][3]int{
+		{0, 11, 5}, {0, 5, 1}, {0, 1, 7}, {0, 7, 10}, {0, 10, 11},
+		{1, 5, 9}, {5, 11, 4}, {11, 10, 2}, {10, 7, 6}, {7, 1, 8},
+		{3, 9, 4}, {3, 4, 2}, {3, 2, 6}, {3, 6, 8}, {3, 8, 9},
+		{4, 9, 5}, {2, 4, 11}, {6, 2, 10}, {8, 6, 7}, {9, 8, 1},
+	}
+
+	for i := 0; i < subdivisions; i++ {
+		midpoints := make(map[[2]int]int)
+		midpoint := func(a, b int) int {
+			key := [2]int{min(a, b), max(a, b)}
+			if m, ok := midpoints[key]; ok {
+				return m
+			}
+			points = append(points, points[a].Add(points[b]).Normalize())
+			midpoints[key] = len(points) - 1
+			return len(points) - 1
+		}
+
+		subdivided := make([][3]int, 0, len(faces)*4)
+		for _, f := range faces {
+			ab, bc, ca := midpoint(f[0], f[1]), midpoint(f[1], f[2]), midpoint(f[2], f[0])
+			subdivided = append(subdivided,
+				[3]int{f[0], ab, ca},
+				[3]int{f[1], bc, ab},
+				[3]int{f[2], ca, bc},
+				[3]int{ab, bc, ca},
+			)
+		}
+		faces = subdivided
+	}
+
+	//UVs are worked out per triangle so the ones crossing the seam
+	//can be wrapped, the verticies are then welded back together
+	m := MeshData{}
+	for _, f := range faces {
+		var uvs [3]mgl32.Vec2
+		for i, p := range f {
+			uvs[i] = sphereUV(points[p])
+		}
+		fixSphereSeam(&uvs, [3]mgl32.Vec3{points[f[0]], points[f[1]], points[f[2]]})
+
+		for i, p := range f {
+			m.Positions = append(m.Positions, points[p].Mul(radius))
+			m.UVs = append(m.UVs, uvs[i])
+			m.Normals = append(m.Normals, points[p])
+		}
+	}
+	m.Weld()
+	return &m
+}
+
+// equirectangular UV of a direction, laid out the same way as UVSphere's
+func sphereUV(dir mgl32.Vec3) mgl32.Vec2 {
+	u := float32(math.Atan2(float64(dir.Z()), float64(dir.X())) / (2 * math.Pi))
+	if u < 0 {
+		u += 1
+	}
+	v := 0.5 + float32(math.Asin(float64(mgl32.Clamp(dir.Y(), -1, 1)))/math.Pi)
+	return mgl32.Vec2{u, v}
+}
+
+func fixSphereSeam(uvs *[3]mgl32.Vec2, dirs [3]mgl32.Vec3) {
+	//triangles spanning more than half the texture cross the seam
+	for i := range uvs {
+		for j := range uvs {
+			if uvs[j].X()-uvs[i].X() > 0.5 {
+				uvs[i][0] += 1
+			}
+		}
+	}
+
+	//the U at a pole is meaningless so use the middle of the other two
+	for i, d := range dirs {
+		if mgl32.Abs(d.X()) < 1e-6 && mgl32.Abs(d.Z()) < 1e-6 {
+			other1, other2 := uvs[(i+1)%3], uvs[(i+2)%3]
+			uvs[i][0] = (other1.X() + other2.X()) / 2
+		}
+	}
+}
+
+// a capped cylinder standing on the Y axis
+func Cylinder(radius, height float32, segments int) Object {
+	return CylinderMesh(radius, height, segments).Upload()
+}
+
+func CylinderMesh(radius, height float32, segments int) *MeshData {
+	segments = max(segments, 3)
+
+	b := meshBuilder{}
+	b.addGrid(1, segments, false, func(r, c int) (mgl32.Vec3, mgl32.Vec2, mgl32.Vec3) {
+		angle := 2 * math.Pi * float32(c) / float32(segments)
+		normal := mgl32.Vec3{Cos32(angle), 0, Sin32(angle)}
+
+		pos := normal.Mul(radius).Add(mgl32.Vec3{0, height/2 - height*float32(r), 0})
+		uv := mgl32.Vec2{float32(c) / float32(segments), 1 - float32(r)}
+		return pos, uv, normal
+	})
+	b.addDisc(radius, height/2, segments, false)
+	b.addDisc(radius, -height/2, segments, true)
+	return b.result()
+}
+
+// a cone with its base centred on -height/2 and its tip at height/2
+func Cone(radius, height float32, segments int) Object {
+	return ConeMesh(radius, height, segments).Upload()
+}
+
+func ConeMesh(radius, height float32, segments int) *MeshData {
+	segments = max(segments, 3)
+
+	//the side normals lean up by the slope of the cone
+	slope := mgl32.Vec2{height, radius}.Normalize()
+
+	b := meshBuilder{}
+	b.addGrid(1, segments, false, func(r, c int) (mgl32.Vec3, mgl32.Vec2, mgl32.Vec3) {
+		angle := 2 * math.Pi * float32(c) / float32(segments)
+		dir := mgl32.Vec3{Cos32(angle), 0, Sin32(angle)}
+
+		pos := dir.Mul(radius * float32(r)).Add(mgl32.Vec3{0, height/2 - height*float32(r), 0})
+		normal := dir.Mul(slope.X()).Add(mgl32.Vec3{0, slope.Y(), 0})
+		uv := mgl32.Vec2{float32(c) / float32(segments), 1 - float32(r)}
+		return pos, uv, normal
+	})
+	b.addDisc(radius, -height/2, segments, true)
+	return b.result()
+}
+
+// a ring lying in the XZ plane, majorRadius is measured to the centre of the tube
+func Torus(majorRadius, minorRadius float32, majorSegments, minorSegments int) Object {
+	return TorusMesh(majorRadius, minorRadius, majorSegments, minorSegments).Upload()
+}
+
+func TorusMesh(majorRadius, minorRadius float32, majorSegments, minorSegments int) *MeshData {
+	majorSegments = max(majorSegments, 3)
+	minorSegments = max(minorSegments, 3)
+
+	b := meshBuilder{}
+	b.addGrid(minorSegments, majorSegments, false, func(r, c int) (mgl32.Vec3, mgl32.Vec2, mgl32.Vec3) {
+		theta := 2 * math.Pi * float32(c) / float32(majorSegments)
+		phi := -2 * math.Pi * float32(r) / float32(minorSegments)
+
+		centre := mgl32.Vec3{Cos32(theta), 0, Sin32(theta)}.Mul(majorRadius)
+		normal := mgl32.Vec3{Cos32(phi) * Cos32(theta), Sin32(phi), Cos32(phi) * Sin32(theta)}
+		uv := mgl32.Vec2{float32(c) / float32(majorSegments), 1 - float32(r)/float32(minorSegments)}
+		return centre.Add(normal.Mul(minorRadius)), uv, normal
+	})
+	return b.result()
+}
+
+// a flat grid in the XZ plane facing +Y
+// subdivisions are the number of cells along each side
+func Plane(width, depth float32, subdivisionsX, subdivisionsZ int) Object {
+	return PlaneMesh(width, depth, subdivisionsX, subdivisionsZ).Upload()
+}
+
+func PlaneMesh(width, depth float32, subdivisionsX, subdivisionsZ int) *MeshData {
+	subdivisionsX = max(subdivisionsX, 1)
+	subdivisionsZ = max(subdivisionsZ, 1)
+
+	b := meshBuilder{}
+	b.addGrid(subdivisionsX, subdivisionsZ, false, func(r, c int) (mgl32.Vec3, mgl32.Vec2, mgl32.Vec3) {
+		u := float32(r) / float32(subdivisionsX)
+		v := float32(c) / float32(subdivisionsZ)
+
+		pos := mgl32.Vec3{width * (u - 0.5), 0, depth * (v - 0.5)}
+		return pos, mgl32.Vec2{u, 1 - v}, mgl32.Vec3{0, 1, 0}
+	})
+	return b.result()
+}
+
+// a cylinder of the given height with a hemisphere on each end
+// rings is the number of rings in each hemisphere
+func Capsule(radius, height float32, segments, rings int) Object {
+	return CapsuleMesh(radius, height, segments, rings).Upload()
+}
+
+func CapsuleMesh(radius, height float32, segments, rings int) *MeshData {
+	segments = max(segments, 3)
+	rings = max(rings, 1)
+
+	totalHeight := height + 2*radius
+
+	//the equator row is repeated so the two hemispheres
+	//are joined by the straight cylinder section
+	b := meshBuilder{}
+	b.addGrid(2*rings+1, segments, false, func(r, c int) (mgl32.Vec3, mgl32.Vec2, mgl32.Vec3) {
+		offset := height / 2
+		ring := r
+		if r > rings {
+			offset = -height / 2
+			ring = r - 1
+		}
+
+		normal := sphereDirection(math.Pi*float32(ring)/float32(2*rings), 2*math.Pi*float32(c)/float32(segments))
+		pos := normal.Mul(radius).Add(mgl32.Vec3{0, offset, 0})
+		uv := mgl32.Vec2{float32(c) / float32(segments), (pos.Y() + totalHeight/2) / totalHeight}
+		return pos, uv, normal
+	})
+	return b.result()
+}
+
+// a flat circle in the XZ plane facing +Y
+func Disc(radius float32, segments int) Object {
+	return DiscMesh(radius, segments).Upload()
+}
+
+func DiscMesh(radius float32, segments int) *MeshData {
+	segments = max(segments, 3)
+
+	b := meshBuilder{}
+	b.addDisc(radius, 0, segments, false)
+	return b.result()
+}
@@ -0,0 +1,898 @@
+package helpers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+/*
+A Backend that doesn't need a GPU. It keeps track of the objects
+that have been made, what is bound and every draw call, so tests can
+check what the helpers asked GL to do e.g.
+
+	b := helpers.NewRecordingBackend()
+	helpers.UseBackend(b)
+	cube := helpers.Cube(1)
+	cube.Draw(shader, mgl32.Ident4())
+	draw := b.Draws[0] //draw.VertexArray, draw.Program, draw.Count...
+
+Shaders always compile and link unless CompileResult or LinkResult say otherwise.
+Programs get their vertex inputs, uniforms and uniform blocks from simple
+scans of their source so introspection gives sensible answers.
+*/
+
+type RecordingBackend struct {
+	Calls  []string //every call made, e.g. "BindVertexArray(3)"
+	Errors []string //calls GL would have raised an error for
+	Draws  []RecordedDraw
+
+	Buffers      map[uint32][]byte //the contents of every live buffer
+	VertexArrays map[uint32]*RecordedVertexArray
+	Textures     map[uint32]*RecordedTexture
+	Shaders      map[uint32]*RecordedShader
+	Programs     map[uint32]*RecordedProgram
+
+	BoundBuffers      map[uint32]uint32 //target -> buffer, ELEMENT_ARRAY_BUFFER is read from the bound VAO
+	BoundVertexArray  uint32
+	CurrentProgram    uint32
+	ActiveTextureUnit uint32            //0 based, not gl.TEXTURE0 based
+	BoundTextures     map[uint32]uint32 //unit -> texture
+	Enabled           map[uint32]bool
+
+	//decides if a shader compiles, nil compiles everything
+	CompileResult func(stage uint32, source string) (ok bool, log string)
+	//decides if a program links, nil links everything
+	LinkResult func(shaders []*RecordedShader) (ok bool, log string)
+	//what GetActiveUniformBlockiv gives for UNIFORM_BLOCK_DATA_SIZE
+	UniformBlockSizes map[string]int32
+
+	nextID uint32
+}
+
+type RecordedDraw struct {
+	Mode        uint32
+	First       int32 //for array draws
+	Count       int32
+	IndexType   uint32 //0 for array draws
+	Offset      uintptr
+	Instances   int32 //0 if it wasn't instanced
+	VertexArray uint32
+	Program     uint32
+	Textures    map[uint32]uint32 //unit -> texture bound when it was drawn
+}
+
+type RecordedVertexArray struct {
+	Attributes    map[uint32]*RecordedAttribute
+	ElementBuffer uint32
+}
+
+type RecordedAttribute struct {
+	Buffer     uint32
+	Size       int32
+	Type       uint32
+	Normalized bool
+	Integer    bool
+	Stride     int32
+	Offset     uintptr
+	Enabled    bool
+	Divisor    uint32
+}
+
+type RecordedTexture struct {
+	Width, Height int32
+	Format        uint32
+	Parameters    map[uint32]int32
+	Mipmapped     bool
+}
+
+type RecordedShader struct {
+	Stage    uint32
+	Source   string
+	Compiled bool
+	Log      string
+}
+
+type RecordedProgram struct {
+	Shaders       []uint32
+	Linked        bool
+	Log           string
+	Inputs        []RecordedInput
+	Uniforms      []string          //the location of a uniform is its index
+	Active        []RecordedUniform //what GetActiveUniform reports, one per array
+	UniformValues map[string]any    //the last value set for each uniform
+	Blocks        []string          //the index of a block is its index
+	BlockBindings map[string]uint32 //block -> binding point
+}
+
+type RecordedUniform struct {
+	Name string //arrays are name[0]
+	Type uint32
+	Size int32
+}
+
+type RecordedInput struct {
+	Name     string
+	Location uint32
+	Type     uint32
+}
+
+func NewRecordingBackend() *RecordingBackend {
+	b := RecordingBackend{
+		Buffers:           make(map[uint32][]byte),
+		VertexArrays:      make(map[uint32]*RecordedVertexArray),
+		Textures:          make(map[uint32]*RecordedTexture),
+		Shaders:           make(map[uint32]*RecordedShader),
+		Programs:          make(map[uint32]*RecordedProgram),
+		BoundBuffers:      make(map[uint32]uint32),
+		BoundTextures:     make(map[uint32]uint32),
+		Enabled:           make(map[uint32]bool),
+		UniformBlockSizes: make(map[string]int32),
+	}
+	return &b
+}
+
+func (b *RecordingBackend) record(name string, args ...any) {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = fmt.Sprint(a)
+	}
+	b.Calls = append(b.Calls, fmt.Sprintf("%s(%s)", name, strings.Join(parts, ", ")))
+}
+
+func (b *RecordingBackend) fail(format string, args ...any) {
+	b.Errors = append(b.Errors, fmt.Sprintf(format, args...))
+}
+
+// all the object kinds share one id counter so mixing them up shows in tests
+func (b *RecordingBackend) newID() uint32 {
+	b.nextID++
+	return b.nextID
+}
+
+// how many of the recorded calls were to the named function
+func (b *RecordingBackend) CallCount(name string) int {
+	n := 0
+	for _, c := range b.Calls {
+		if strings.HasPrefix(c, name+"(") {
+			n++
+		}
+	}
+	return n
+}
+
+// the last value set for a uniform of the program, nil if it was never set
+func (b *RecordingBackend) Uniform(program uint32, name string) any {
+	p, ok := b.Programs[program]
+	if !ok {
+		return nil
+	}
+	return p.UniformValues[name]
+}
+
+func (b *RecordingBackend) boundBuffer(target uint32) (uint32, bool) {
+	if target == gl.ELEMENT_ARRAY_BUFFER {
+		vao, ok := b.VertexArrays[b.BoundVertexArray]
+		if !ok {
+			b.fail("no vertex array is bound for ELEMENT_ARRAY_BUFFER")
+			return 0, false
+		}
+		return vao.ElementBuffer, vao.ElementBuffer != 0
+	}
+	buffer, ok := b.BoundBuffers[target]
+	if !ok || buffer == 0 {
+		b.fail("no buffer is bound to 0x%x", target)
+		return 0, false
+	}
+	return buffer, true
+}
+
+func (b *RecordingBackend) GenBuffer() uint32 {
+	id := b.newID()
+	b.record("GenBuffer")
+	b.Buffers[id] = nil
+	return id
+}
+func (b *RecordingBackend) DeleteBuffer(buffer uint32) {
+	b.record("DeleteBuffer", buffer)
+	if _, ok := b.Buffers[buffer]; !ok {
+		b.fail("DeleteBuffer(%d): not a buffer", buffer)
+	}
+	delete(b.Buffers, buffer)
+	for target, bound := range b.BoundBuffers {
+		if bound == buffer {
+			delete(b.BoundBuffers, target)
+		}
+	}
+}
+func (b *RecordingBackend) BindBuffer(target, buffer uint32) {
+	b.record("BindBuffer", target, buffer)
+	if _, ok := b.Buffers[buffer]; !ok && buffer != 0 {
+		b.fail("BindBuffer(%d): not a buffer", buffer)
+	}
+	if target == gl.ELEMENT_ARRAY_BUFFER {
+		if vao, ok := b.VertexArrays[b.BoundVertexArray]; ok {
+			vao.ElementBuffer = buffer
+		} else {
+			b.fail("BindBuffer(ELEMENT_ARRAY_BUFFER, %d): no vertex array is bound", buffer)
+		}
+		return
+	}
+	b.BoundBuffers[target] = buffer
+}
+func (b *RecordingBackend) BindBufferBase(target, index, buffer uint32) {
+	b.record("BindBufferBase", target, index, buffer)
+	b.BoundBuffers[target] = buffer
+}
+func (b *RecordingBackend) BufferData(target uint32, size int, data unsafe.Pointer, usage uint32) {
+	b.record("BufferData", target, size, usage)
+	if buffer, ok := b.boundBuffer(target); ok {
+		contents := make([]byte, size)
+		if data != nil {
+			copy(contents, unsafe.Slice((*byte)(data), size))
+		}
+		b.Buffers[buffer] = contents
+	}
+}
+func (b *RecordingBackend) BufferSubData(target uint32, offset, size int, data unsafe.Pointer) {
+	b.record("BufferSubData", target, offset, size)
+	if buffer, ok := b.boundBuffer(target); ok {
+		if offset+size > len(b.Buffers[buffer]) {
+			b.fail("BufferSubData: %d bytes at %d is past the end of buffer %d", size, offset, buffer)
+			return
+		}
+		copy(b.Buffers[buffer][offset:], unsafe.Slice((*byte)(data), size))
+	}
+}
+func (b *RecordingBackend) MapBufferRange(target uint32, offset, length int, access uint32) unsafe.Pointer {
+	b.record("MapBufferRange", target, offset, length, access)
+	buffer, ok := b.boundBuffer(target)
+	if !ok || offset+length > len(b.Buffers[buffer]) || length == 0 {
+		b.fail("MapBufferRange: can't map %d bytes at %d", length, offset)
+		return nil
+	}
+	return unsafe.Pointer(&b.Buffers[buffer][offset])
+}
+func (b *RecordingBackend) UnmapBuffer(target uint32) bool {
+	b.record("UnmapBuffer", target)
+	return true
+}
+
+func (b *RecordingBackend) GenVertexArray() uint32 {
+	id := b.newID()
+	b.record("GenVertexArray")
+	b.VertexArrays[id] = &RecordedVertexArray{Attributes: make(map[uint32]*RecordedAttribute)}
+	return id
+}
+func (b *RecordingBackend) DeleteVertexArray(vao uint32) {
+	b.record("DeleteVertexArray", vao)
+	if _, ok := b.VertexArrays[vao]; !ok {
+		b.fail("DeleteVertexArray(%d): not a vertex array", vao)
+	}
+	delete(b.VertexArrays, vao)
+	if b.BoundVertexArray == vao {
+		b.BoundVertexArray = 0
+	}
+}
+func (b *RecordingBackend) BindVertexArray(vao uint32) {
+	b.record("BindVertexArray", vao)
+	if _, ok := b.VertexArrays[vao]; !ok && vao != 0 {
+		b.fail("BindVertexArray(%d): not a vertex array", vao)
+	}
+	b.BoundVertexArray = vao
+}
+
+// the attribute of the bound VAO, nil if there isn't a VAO bound
+func (b *RecordingBackend) attribute(index uint32) *RecordedAttribute {
+	vao, ok := b.VertexArrays[b.BoundVertexArray]
+	if !ok {
+		b.fail("attribute %d: no vertex array is bound", index)
+		return nil
+	}
+	a, ok := vao.Attributes[index]
+	if !ok {
+		a = &RecordedAttribute{}
+		vao.Attributes[index] = a
+	}
+	return a
+}
+func (b *RecordingBackend) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset uintptr) {
+	b.record("VertexAttribPointer", index, size, xtype, normalized, stride, offset)
+	if a := b.attribute(index); a != nil {
+		a.Buffer, a.Size, a.Type, a.Normalized, a.Integer, a.Stride, a.Offset = b.BoundBuffers[gl.ARRAY_BUFFER], size, xtype, normalized, false, stride, offset
+	}
+}
+func (b *RecordingBackend) VertexAttribIPointer(index uint32, size int32, xtype uint32, stride int32, offset uintptr) {
+	b.record("VertexAttribIPointer", index, size, xtype, stride, offset)
+	if a := b.attribute(index); a != nil {
+		a.Buffer, a.Size, a.Type, a.Normalized, a.Integer, a.Stride, a.Offset = b.BoundBuffers[gl.ARRAY_BUFFER], size, xtype, false, true, stride, offset
+	}
+}
+func (b *RecordingBackend) EnableVertexAttribArray(index uint32) {
+	b.record("EnableVertexAttribArray", index)
+	if a := b.attribute(index); a != nil {
+		a.Enabled = true
+	}
+}
+func (b *RecordingBackend) VertexAttribDivisor(index, divisor uint32) {
+	b.record("VertexAttribDivisor", index, divisor)
+	if a := b.attribute(index); a != nil {
+		a.Divisor = divisor
+	}
+}
+
+func (b *RecordingBackend) draw(d RecordedDraw) {
+	d.VertexArray, d.Program = b.BoundVertexArray, b.CurrentProgram
+	d.Textures = make(map[uint32]uint32, len(b.BoundTextures))
+	for unit, texture := range b.BoundTextures {
+		d.Textures[unit] = texture
+	}
+	if d.VertexArray == 0 {
+		b.fail("draw with no vertex array bound")
+	}
+	if d.Program == 0 {
+		b.fail("draw with no program in use")
+	}
+	if d.IndexType != 0 {
+		if vao, ok := b.VertexArrays[d.VertexArray]; ok && vao.ElementBuffer == 0 {
+			b.fail("indexed draw with no element buffer")
+		}
+	}
+	b.Draws = append(b.Draws, d)
+}
+func (b *RecordingBackend) DrawArrays(mode uint32, first, count int32) {
+	b.record("DrawArrays", mode, first, count)
+	b.draw(RecordedDraw{Mode: mode, First: first, Count: count})
+}
+func (b *RecordingBackend) DrawElements(mode uint32, count int32, xtype uint32, offset uintptr) {
+	b.record("DrawElements", mode, count, xtype, offset)
+	b.draw(RecordedDraw{Mode: mode, Count: count, IndexType: xtype, Offset: offset})
+}
+func (b *RecordingBackend) DrawArraysInstanced(mode uint32, first, count, instances int32) {
+	b.record("DrawArraysInstanced", mode, first, count, instances)
+	b.draw(RecordedDraw{Mode: mode, First: first, Count: count, Instances: instances})
+}
+func (b *RecordingBackend) DrawElementsInstanced(mode uint32, count int32, xtype uint32, offset uintptr, instances int32) {
+	b.record("DrawElementsInstanced", mode, count, xtype, offset, instances)
+	b.draw(RecordedDraw{Mode: mode, Count: count, IndexType: xtype, Offset: offset, Instances: instances})
+}
+
+func (b *RecordingBackend) GenTexture() uint32 {
+	id := b.newID()
+	b.record("GenTexture")
+	b.Textures[id] = &RecordedTexture{Parameters: make(map[uint32]int32)}
+	return id
+}
+func (b *RecordingBackend) DeleteTexture(texture uint32) {
+	b.record("DeleteTexture", texture)
+	if _, ok := b.Textures[texture]; !ok {
+		b.fail("DeleteTexture(%d): not a texture", texture)
+	}
+	delete(b.Textures, texture)
+}
+func (b *RecordingBackend) ActiveTexture(unit uint32) {
+	b.record("ActiveTexture", unit)
+	b.ActiveTextureUnit = unit - gl.TEXTURE0
+}
+func (b *RecordingBackend) BindTexture(target, texture uint32) {
+	b.record("BindTexture", target, texture)
+	if _, ok := b.Textures[texture]; !ok && texture != 0 {
+		b.fail("BindTexture(%d): not a texture", texture)
+	}
+	b.BoundTextures[b.ActiveTextureUnit] = texture
+}
+func (b *RecordingBackend) boundTexture() *RecordedTexture {
+	t, ok := b.Textures[b.BoundTextures[b.ActiveTextureUnit]]
+	if !ok {
+		b.fail("no texture bound to unit %d", b.ActiveTextureUnit)
+	}
+	return t
+}
+func (b *RecordingBackend) TexImage2D(target uint32, level, internalFormat, width, height int32, format, xtype uint32, pixels unsafe.Pointer) {
+	b.record("TexImage2D", target, level, internalFormat, width, height, format, xtype)
+	if t := b.boundTexture(); t != nil && level == 0 {
+		t.Width, t.Height, t.Format = width, height, uint32(internalFormat)
+	}
+}
+func (b *RecordingBackend) TexParameteri(target, pname uint32, param int32) {
+	b.record("TexParameteri", target, pname, param)
+	if t := b.boundTexture(); t != nil {
+		t.Parameters[pname] = param
+	}
+}
+func (b *RecordingBackend) GenerateMipmap(target uint32) {
+	b.record("GenerateMipmap", target)
+	if t := b.boundTexture(); t != nil {
+		t.Mipmapped = true
+	}
+}
+
+func (b *RecordingBackend) CreateShader(stage uint32) uint32 {
+	id := b.newID()
+	b.record("CreateShader", stage)
+	b.Shaders[id] = &RecordedShader{Stage: stage}
+	return id
+}
+func (b *RecordingBackend) shader(name string, shader uint32) *RecordedShader {
+	s, ok := b.Shaders[shader]
+	if !ok {
+		b.fail("%s(%d): not a shader", name, shader)
+	}
+	return s
+}
+func (b *RecordingBackend) ShaderSource(shader uint32, source string) {
+	b.record("ShaderSource", shader)
+	if s := b.shader("ShaderSource", shader); s != nil {
+		s.Source = source
+	}
+}
+func (b *RecordingBackend) CompileShader(shader uint32) {
+	b.record("CompileShader", shader)
+	if s := b.shader("CompileShader", shader); s != nil {
+		s.Compiled, s.Log = true, ""
+		if b.CompileResult != nil {
+			s.Compiled, s.Log = b.CompileResult(s.Stage, s.Source)
+		}
+	}
+}
+func (b *RecordingBackend) GetShaderiv(shader, pname uint32) int32 {
+	b.record("GetShaderiv", shader, pname)
+	s := b.shader("GetShaderiv", shader)
+	if s == nil {
+		return 0
+	}
+	switch pname {
+	case gl.COMPILE_STATUS:
+		if s.Compiled {
+			return gl.TRUE
+		}
+		return gl.FALSE
+	case gl.INFO_LOG_LENGTH:
+		return infoLogLength(s.Log)
+	case gl.SHADER_TYPE:
+		return int32(s.Stage)
+	}
+	b.fail("GetShaderiv: unsupported parameter 0x%x", pname)
+	return 0
+}
+func (b *RecordingBackend) GetShaderInfoLog(shader uint32, bufSize int32) string {
+	b.record("GetShaderInfoLog", shader, bufSize)
+	if s := b.shader("GetShaderInfoLog", shader); s != nil {
+		return truncateLog(s.Log, bufSize)
+	}
+	return ""
+}
+func (b *RecordingBackend) DeleteShader(shader uint32) {
+	b.record("DeleteShader", shader)
+	if b.shader("DeleteShader", shader) != nil {
+		delete(b.Shaders, shader)
+	}
+}
+
+// GL counts the null terminator, an empty log has a length of 0
+func infoLogLength(log string) int32 {
+	if log == "" {
+		return 0
+	}
+	return int32(len(log) + 1)
+}
+
+func truncateLog(log string, bufSize int32) string {
+	if bufSize <= 0 {
+		return ""
+	}
+	if int(bufSize-1) < len(log) {
+		return log[:bufSize-1]
+	}
+	return log
+}
+
+func (b *RecordingBackend) CreateProgram() uint32 {
+	id := b.newID()
+	b.record("CreateProgram")
+	b.Programs[id] = &RecordedProgram{
+		UniformValues: make(map[string]any),
+		BlockBindings: make(map[string]uint32),
+	}
+	return id
+}
+func (b *RecordingBackend) program(name string, program uint32) *RecordedProgram {
+	p, ok := b.Programs[program]
+	if !ok {
+		b.fail("%s(%d): not a program", name, program)
+	}
+	return p
+}
+func (b *RecordingBackend) AttachShader(program, shader uint32) {
+	b.record("AttachShader", program, shader)
+	p := b.program("AttachShader", program)
+	if p != nil && b.shader("AttachShader", shader) != nil {
+		p.Shaders = append(p.Shaders, shader)
+	}
+}
+func (b *RecordingBackend) LinkProgram(program uint32) {
+	b.record("LinkProgram", program)
+	p := b.program("LinkProgram", program)
+	if p == nil {
+		return
+	}
+
+	shaders := make([]*RecordedShader, 0, len(p.Shaders))
+	for _, id := range p.Shaders {
+		if s, ok := b.Shaders[id]; ok {
+			shaders = append(shaders, s)
+		}
+	}
+	p.Linked, p.Log = true, ""
+	for _, s := range shaders {
+		if !s.Compiled {
+			p.Linked, p.Log = false, "error: a shader failed to compile"
+		}
+	}
+	if p.Linked && b.LinkResult != nil {
+		p.Linked, p.Log = b.LinkResult(shaders)
+	}
+	if p.Linked {
+		scanProgram(p, shaders)
+	}
+}
+func (b *RecordingBackend) GetProgramiv(program, pname uint32) int32 {
+	b.record("GetProgramiv", program, pname)
+	p := b.program("GetProgramiv", program)
+	if p == nil {
+		return 0
+	}
+	switch pname {
+	case gl.LINK_STATUS:
+		if p.Linked {
+			return gl.TRUE
+		}
+		return gl.FALSE
+	case gl.INFO_LOG_LENGTH:
+		return infoLogLength(p.Log)
+	case gl.ACTIVE_ATTRIBUTES:
+		return int32(len(p.Inputs))
+	case gl.ACTIVE_ATTRIBUTE_MAX_LENGTH:
+		longest := 0
+		for _, in := range p.Inputs {
+			longest = max(longest, len(in.Name)+1)
+		}
+		return int32(longest)
+	case gl.ACTIVE_UNIFORMS:
+		return int32(len(p.Active))
+	case gl.ACTIVE_UNIFORM_MAX_LENGTH:
+		longest := 0
+		for _, u := range p.Active {
+			longest = max(longest, len(u.Name)+1)
+		}
+		return int32(longest)
+	case gl.ACTIVE_UNIFORM_BLOCKS:
+		return int32(len(p.Blocks))
+	case gl.ATTACHED_SHADERS:
+		return int32(len(p.Shaders))
+	}
+	b.fail("GetProgramiv: unsupported parameter 0x%x", pname)
+	return 0
+}
+func (b *RecordingBackend) GetProgramInfoLog(program uint32, bufSize int32) string {
+	b.record("GetProgramInfoLog", program, bufSize)
+	if p := b.program("GetProgramInfoLog", program); p != nil {
+		return truncateLog(p.Log, bufSize)
+	}
+	return ""
+}
+func (b *RecordingBackend) UseProgram(program uint32) {
+	b.record("UseProgram", program)
+	if program != 0 {
+		if p := b.program("UseProgram", program); p == nil || !p.Linked {
+			b.fail("UseProgram(%d): program isn't linked", program)
+		}
+	}
+	b.CurrentProgram = program
+}
+func (b *RecordingBackend) DeleteProgram(program uint32) {
+	b.record("DeleteProgram", program)
+	if b.program("DeleteProgram", program) != nil {
+		delete(b.Programs, program)
+	}
+	if b.CurrentProgram == program {
+		b.CurrentProgram = 0
+	}
+}
+
+func (b *RecordingBackend) GetActiveAttrib(program, index uint32) (string, int32, uint32) {
+	b.record("GetActiveAttrib", program, index)
+	p := b.program("GetActiveAttrib", program)
+	if p == nil || int(index) >= len(p.Inputs) {
+		b.fail("GetActiveAttrib(%d, %d): no such attribute", program, index)
+		return "", 0, 0
+	}
+	in := p.Inputs[index]
+	return in.Name, 1, in.Type
+}
+func (b *RecordingBackend) GetAttribLocation(program uint32, name string) int32 {
+	b.record("GetAttribLocation", program, name)
+	if p := b.program("GetAttribLocation", program); p != nil {
+		for _, in := range p.Inputs {
+			if in.Name == name {
+				return int32(in.Location)
+			}
+		}
+	}
+	return -1
+}
+func (b *RecordingBackend) GetActiveUniform(program, index uint32) (string, int32, uint32) {
+	b.record("GetActiveUniform", program, index)
+	p := b.program("GetActiveUniform", program)
+	if p == nil || int(index) >= len(p.Active) {
+		b.fail("GetActiveUniform(%d, %d): no such uniform", program, index)
+		return "", 0, 0
+	}
+	u := p.Active[index]
+	return u.Name, u.Size, u.Type
+}
+func (b *RecordingBackend) GetUniformLocation(program uint32, name string) int32 {
+	b.record("GetUniformLocation", program, name)
+	if p := b.program("GetUniformLocation", program); p != nil {
+		for i, u := range p.Uniforms {
+			if u == name || u == name+"[0]" {
+				return int32(i)
+			}
+		}
+	}
+	return -1
+}
+func (b *RecordingBackend) GetUniformBlockIndex(program uint32, name string) uint32 {
+	b.record("GetUniformBlockIndex", program, name)
+	if p := b.program("GetUniformBlockIndex", program); p != nil {
+		for i, block := range p.Blocks {
+			if block == name {
+				return uint32(i)
+			}
+		}
+	}
+	return gl.INVALID_INDEX
+}
+func (b *RecordingBackend) GetActiveUniformBlockiv(program, block, pname uint32) int32 {
+	b.record("GetActiveUniformBlockiv", program, block, pname)
+	p := b.program("GetActiveUniformBlockiv", program)
+	if p == nil || int(block) >= len(p.Blocks) {
+		b.fail("GetActiveUniformBlockiv(%d, %d): no such block", program, block)
+		return 0
+	}
+	switch pname {
+	case gl.UNIFORM_BLOCK_DATA_SIZE:
+		return b.UniformBlockSizes[p.Blocks[block]]
+	case gl.UNIFORM_BLOCK_BINDING:
+		return int32(p.BlockBindings[p.Blocks[block]])
+	}
+	b.fail("GetActiveUniformBlockiv: unsupported parameter 0x%x", pname)
+	return 0
+}
+func (b *RecordingBackend) UniformBlockBinding(program, block, binding uint32) {
+	b.record("UniformBlockBinding", program, block, binding)
+	p := b.program("UniformBlockBinding", program)
+	if p == nil || int(block) >= len(p.Blocks) {
+		b.fail("UniformBlockBinding(%d, %d): no such block", program, block)
+		return
+	}
+	p.BlockBindings[p.Blocks[block]] = binding
+}
+
+// stores a value for a uniform of the current program
+func (b *RecordingBackend) setUniform(location int32, value any) {
+	if location == -1 {
+		return //GL ignores -1 so missing uniforms are silent
+	}
+	p, ok := b.Programs[b.CurrentProgram]
+	if !ok {
+		b.fail("setting uniform %d with no program in use", location)
+		return
+	}
+	if location < 0 || int(location) >= len(p.Uniforms) {
+		b.fail("uniform location %d isn't in program %d", location, b.CurrentProgram)
+		return
+	}
+	p.UniformValues[p.Uniforms[location]] = value
+}
+func (b *RecordingBackend) Uniform1f(location int32, v float32) {
+	b.record("Uniform1f", location, v)
+	b.setUniform(location, v)
+}
+func (b *RecordingBackend) Uniform1i(location int32, v int32) {
+	b.record("Uniform1i", location, v)
+	b.setUniform(location, v)
+}
+func (b *RecordingBackend) Uniform1fv(location int32, count int32, v *float32) {
+	b.record("Uniform1fv", location, count)
+	b.setUniform(location, append([]float32(nil), unsafe.Slice(v, count)...))
+}
+func (b *RecordingBackend) Uniform1iv(location int32, count int32, v *int32) {
+	b.record("Uniform1iv", location, count)
+	b.setUniform(location, append([]int32(nil), unsafe.Slice(v, count)...))
+}
+func (b *RecordingBackend) Uniform2fv(location int32, count int32, v *float32) {
+	b.record("Uniform2fv", location, count)
+	b.setUniform(location, append([]float32(nil), unsafe.Slice(v, 2*count)...))
+}
+func (b *RecordingBackend) Uniform3fv(location int32, count int32, v *float32) {
+	b.record("Uniform3fv", location, count)
+	b.setUniform(location, append([]float32(nil), unsafe.Slice(v, 3*count)...))
+}
+func (b *RecordingBackend) Uniform4fv(location int32, count int32, v *float32) {
+	b.record("Uniform4fv", location, count)
+	b.setUniform(location, append([]float32(nil), unsafe.Slice(v, 4*count)...))
+}
+func (b *RecordingBackend) UniformMatrix3fv(location int32, count int32, transpose bool, v *float32) {
+	b.record("UniformMatrix3fv", location, count, transpose)
+	b.setUniform(location, append([]float32(nil), unsafe.Slice(v, 9*count)...))
+}
+func (b *RecordingBackend) UniformMatrix4fv(location int32, count int32, transpose bool, v *float32) {
+	b.record("UniformMatrix4fv", location, count, transpose)
+	b.setUniform(location, append([]float32(nil), unsafe.Slice(v, 16*count)...))
+}
+
+func (b *RecordingBackend) FenceSync(condition, flags uint32) uintptr {
+	b.record("FenceSync", condition, flags)
+	return uintptr(b.newID())
+}
+func (b *RecordingBackend) ClientWaitSync(sync uintptr, flags uint32, timeout uint64) uint32 {
+	b.record("ClientWaitSync", sync, flags, timeout)
+	return gl.ALREADY_SIGNALED
+}
+func (b *RecordingBackend) DeleteSync(sync uintptr) {
+	b.record("DeleteSync", sync)
+}
+
+func (b *RecordingBackend) Enable(capability uint32) {
+	b.record("Enable", capability)
+	b.Enabled[capability] = true
+}
+func (b *RecordingBackend) GetString(name uint32) string {
+	b.record("GetString", name)
+	if name == gl.VERSION {
+		return "3.3 (recording backend)"
+	}
+	return ""
+}
+
+var (
+	inputDeclaration   = regexp.MustCompile(`(?m)^\s*(?:layout\s*\(\s*location\s*=\s*(\d+)\s*\)\s*)?in\s+(\w+)\s+(\w+)\s*;`)
+	uniformDeclaration = regexp.MustCompile(`(?m)^\s*uniform\s+(\w+)\s+(\w+)\s*(?:\[\s*(\d+)\s*\])?\s*;`)
+	blockDeclaration   = regexp.MustCompile(`(?m)^\s*(?:layout\s*\([^)]*\)\s*)?uniform\s+(\w+)\s*\{`)
+)
+
+// the GL type enum for a glsl type name
+var glslTypes = make(map[string]uint32)
+
+func init() {
+	for xtype, name := range uniformTypeNames {
+		glslTypes[name] = xtype
+	}
+}
+
+// fills in the program's inputs, uniforms and blocks from its shaders' source
+// this only understands simple one per line declarations
+func scanProgram(p *RecordedProgram, shaders []*RecordedShader) {
+	p.Inputs, p.Uniforms, p.Active, p.Blocks = nil, nil, nil, nil
+	seen := make(map[string]bool)
+
+	for _, s := range shaders {
+		source := activeSource(s.Source)
+		if s.Stage == gl.VERTEX_SHADER {
+			used := make(map[uint32]bool)
+			var unplaced []RecordedInput
+			for _, m := range inputDeclaration.FindAllStringSubmatch(source, -1) {
+				in := RecordedInput{Name: m[3], Type: glslTypes[m[2]]}
+				if m[1] == "" {
+					unplaced = append(unplaced, in)
+					continue
+				}
+				location, _ := strconv.Atoi(m[1])
+				in.Location = uint32(location)
+				for i := 0; i < max(1, int(glslShapes[in.Type].columns)); i++ {
+					used[in.Location+uint32(i)] = true
+				}
+				p.Inputs = append(p.Inputs, in)
+			}
+
+			next := uint32(0)
+			for _, in := range unplaced {
+				for used[next] {
+					next++
+				}
+				in.Location = next
+				for i := 0; i < max(1, int(glslShapes[in.Type].columns)); i++ {
+					used[next] = true
+					next++
+				}
+				p.Inputs = append(p.Inputs, in)
+			}
+		}
+
+		for _, m := range uniformDeclaration.FindAllStringSubmatch(source, -1) {
+			if seen[m[2]] {
+				continue //declared in more than one stage
+			}
+			seen[m[2]] = true
+
+			active := RecordedUniform{Name: m[2], Type: glslTypes[m[1]], Size: 1}
+			names := []string{m[2]}
+			if m[3] != "" {
+				//arrays are reported as name[0] but each element gets a location
+				n, _ := strconv.Atoi(m[3])
+				names = names[:0]
+				for i := 0; i < n; i++ {
+					names = append(names, fmt.Sprintf("%s[%d]", m[2], i))
+				}
+				active.Name, active.Size = names[0], int32(n)
+			}
+			p.Uniforms = append(p.Uniforms, names...)
+			p.Active = append(p.Active, active)
+		}
+		for _, m := range blockDeclaration.FindAllStringSubmatch(source, -1) {
+			if !seen["block "+m[1]] {
+				seen["block "+m[1]] = true
+				p.Blocks = append(p.Blocks, m[1])
+			}
+		}
+	}
+}
+
+var directive = regexp.MustCompile(`^\s*#\s*(\w+)\s*(\w*)`)
+
+// drops the lines #ifdef, #ifndef and #else leave out
+// any other #if is taken as true
+func activeSource(source string) string {
+	defined := make(map[string]bool)
+	var active []bool //one per open conditional
+	isActive := func() bool {
+		return len(active) == 0 || active[len(active)-1]
+	}
+
+	var lines []string
+	for _, line := range strings.Split(source, "\n") {
+		m := directive.FindStringSubmatch(line)
+		if m == nil {
+			if isActive() {
+				lines = append(lines, line)
+			}
+			continue
+		}
+
+		switch m[1] {
+		case "ifdef":
+			active = append(active, isActive() && defined[m[2]])
+		case "ifndef":
+			active = append(active, isActive() && !defined[m[2]])
+		case "if":
+			active = append(active, isActive())
+		case "else":
+			if n := len(active); n > 0 {
+				outer := n == 1 || active[n-2]
+				active[n-1] = outer && !active[n-1]
+			}
+		case "endif":
+			if len(active) > 0 {
+				active = active[:len(active)-1]
+			}
+		case "define":
+			if isActive() {
+				defined[m[2]] = true
+			}
+		case "undef":
+			if isActive() {
+				delete(defined, m[2])
+			}
+		default:
+			if isActive() {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
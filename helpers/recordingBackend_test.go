@@ -0,0 +1,219 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const testVertSource = `#version 330 core
+in vec3 aPos;
+in vec2 aTexCoord;
+
+out vec2 TexCoord;
+
+uniform mat4 model;
+
+void main() {
+	gl_Position = model*vec4(aPos,1.0);
+	TexCoord = aTexCoord;
+}
+`
+
+const testFragSource = `#version 330 core
+out vec4 FragColor;
+
+in vec2 TexCoord;
+
+void main() {
+	FragColor = vec4(TexCoord,0.0,1.0);
+}
+`
+
+func useRecordingBackend(t *testing.T) *RecordingBackend {
+	b := NewRecordingBackend()
+	UseBackend(b)
+	t.Cleanup(func() {
+		for _, err := range b.Errors {
+			t.Error("GL error:", err)
+		}
+	})
+	return b
+}
+
+func writeFile(t *testing.T, path, source string) {
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// a shader built from copies of the sources in a temporary directory
+// returns the path of the vertex shader so it can be changed
+func newTestShader(t *testing.T, vert, frag string) (*Shader, string) {
+	dir := t.TempDir()
+	vertPath, fragPath := filepath.Join(dir, "test.vert"), filepath.Join(dir, "test.frag")
+	writeFile(t, vertPath, vert)
+	writeFile(t, fragPath, frag)
+
+	s := NewShader(vertPath, fragPath)
+	t.Cleanup(s.Delete)
+	return s, vertPath
+}
+
+func TestDrawBindsObjectVAO(t *testing.T) {
+	b := useRecordingBackend(t)
+	shader, _ := newTestShader(t, testVertSource, testFragSource)
+	cube := Cube(1)
+	defer cube.Delete()
+
+	shader.Use()
+	cube.Draw(shader, mgl32.Translate3D(1, 2, 3))
+
+	if len(b.Draws) != 1 {
+		t.Fatalf("got %d draws, want 1", len(b.Draws))
+	}
+	draw := b.Draws[0]
+	vao := cube.vaos[vaoKey{shader.id, false}].id
+	if draw.VertexArray != uint32(vao) {
+		t.Errorf("drew with VAO %d, want the cube's VAO %d", draw.VertexArray, vao)
+	}
+	if draw.Program != uint32(shader.id) {
+		t.Errorf("drew with program %d, want %d", draw.Program, shader.id)
+	}
+	if draw.Count != 36 || draw.IndexType != gl.UNSIGNED_INT {
+		t.Errorf("drew %d indices of type 0x%x, want 36 of type 0x%x", draw.Count, draw.IndexType, gl.UNSIGNED_INT)
+	}
+
+	recorded := b.VertexArrays[uint32(vao)]
+	if recorded.ElementBuffer != uint32(cube.ebo) {
+		t.Errorf("VAO element buffer is %d, want %d", recorded.ElementBuffer, cube.ebo)
+	}
+	for _, input := range []string{"aPos", "aTexCoord"} {
+		location := b.GetAttribLocation(uint32(shader.id), input)
+		a, ok := recorded.Attributes[uint32(location)]
+		if !ok || !a.Enabled || a.Buffer != uint32(cube.vbo) {
+			t.Errorf("input %s isn't fed from the cube's vertex buffer: %+v", input, a)
+		}
+	}
+	want := mgl32.Translate3D(1, 2, 3)
+	if model := b.Uniform(uint32(shader.id), "model"); !reflect.DeepEqual(model, want[:]) {
+		t.Errorf("model uniform is %v, want %v", model, want)
+	}
+}
+
+func TestDrawKeepsOneVAOPerProgram(t *testing.T) {
+	b := useRecordingBackend(t)
+	first, _ := newTestShader(t, testVertSource, testFragSource)
+	second, _ := newTestShader(t, testVertSource, testFragSource)
+	cube := Cube(1)
+	defer cube.Delete()
+
+	for _, s := range []*Shader{first, second, first} {
+		s.Use()
+		cube.Draw(s, mgl32.Ident4())
+	}
+
+	if len(b.Draws) != 3 {
+		t.Fatalf("got %d draws, want 3", len(b.Draws))
+	}
+	if b.Draws[0].VertexArray != b.Draws[2].VertexArray {
+		t.Errorf("drawing with the same program again used VAO %d instead of %d", b.Draws[2].VertexArray, b.Draws[0].VertexArray)
+	}
+	if b.Draws[0].VertexArray == b.Draws[1].VertexArray {
+		t.Errorf("both programs used VAO %d", b.Draws[0].VertexArray)
+	}
+	if n := b.CallCount("GenVertexArray"); n != 2 {
+		t.Errorf("made %d VAOs, want 2", n)
+	}
+}
+
+func TestReloadDeletesOldProgram(t *testing.T) {
+	b := useRecordingBackend(t)
+	shader, vertPath := newTestShader(t, testVertSource, testFragSource)
+	cube := Cube(1)
+	defer cube.Delete()
+
+	shader.Use()
+	cube.Draw(shader, mgl32.Ident4())
+	old := shader.id
+
+	writeFile(t, vertPath, testVertSource+"\n")
+	shader.reload()
+
+	if shader.id == old {
+		t.Fatal("reload kept the old program")
+	}
+	if _, ok := b.Programs[uint32(old)]; ok {
+		t.Errorf("old program %d wasn't deleted", old)
+	}
+
+	shader.Use()
+	cube.Draw(shader, mgl32.Ident4())
+	draw := b.Draws[len(b.Draws)-1]
+	if draw.Program != uint32(shader.id) {
+		t.Errorf("drew with program %d, want the new program %d", draw.Program, shader.id)
+	}
+	if draw.VertexArray == b.Draws[0].VertexArray {
+		t.Errorf("the VAO made for the old program was reused")
+	}
+}
+
+func TestFailedReloadKeepsOldProgram(t *testing.T) {
+	b := useRecordingBackend(t)
+	shader, vertPath := newTestShader(t, testVertSource, testFragSource)
+	old := shader.id
+
+	b.CompileResult = func(stage uint32, source string) (bool, string) {
+		return stage != gl.VERTEX_SHADER, "0:4(1): error: syntax error"
+	}
+	writeFile(t, vertPath, "#version 330 core\nbroken\n")
+	shader.reload()
+
+	if shader.id != old {
+		t.Errorf("program changed to %d after a failed reload, want %d", shader.id, old)
+	}
+	if p, ok := b.Programs[uint32(old)]; !ok || !p.Linked {
+		t.Errorf("old program %d was deleted or unlinked", old)
+	}
+	if n := b.CallCount("DeleteProgram"); n != 0 {
+		t.Errorf("deleted %d programs, want 0", n)
+	}
+}
+
+func TestBrokenShaderDrawsWithModel(t *testing.T) {
+	b := useRecordingBackend(t)
+	ShowShaderErrors(true)
+	t.Cleanup(func() { ShowShaderErrors(false) })
+	b.CompileResult = func(stage uint32, source string) (bool, string) {
+		if strings.Contains(source, "broken") {
+			return false, "0:2(1): error: syntax error"
+		}
+		return true, ""
+	}
+
+	shader, _ := newTestShader(t, "#version 330 core\nbroken\n", testFragSource)
+	if !shader.broken {
+		t.Fatal("the shader isn't using the error program")
+	}
+	cube := Cube(1)
+	defer cube.Delete()
+
+	shader.Use()
+	cube.Draw(shader, mgl32.Translate3D(1, 2, 3))
+
+	if len(b.Draws) != 1 || b.Draws[0].Program != uint32(shader.id) {
+		t.Fatalf("got draws %+v, want one with the error program %d", b.Draws, shader.id)
+	}
+	if n := b.CallCount("UniformMatrix4fv"); n != 1 {
+		t.Errorf("called UniformMatrix4fv %d times, want 1 for model", n)
+	}
+	want := mgl32.Translate3D(1, 2, 3)
+	if model := b.Uniform(uint32(shader.id), "model"); !reflect.DeepEqual(model, want[:]) {
+		t.Errorf("the error program's model uniform is %v, want %v", model, want)
+	}
+}
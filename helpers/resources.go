@@ -0,0 +1,93 @@
+package helpers
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+/*
+Optional tracking of every openGL object the helpers create
+so leaks show up when scenes are reloaded or the app shuts down.
+*/
+
+type resourceKind string
+
+const (
+	bufferResource      resourceKind = "buffer"
+	vertexArrayResource resourceKind = "vertex array"
+	textureResource     resourceKind = "texture"
+	programResource     resourceKind = "program"
+)
+
+type resourceHandle struct {
+	kind resourceKind
+	id   uint32
+}
+
+var (
+	resourceTracking bool
+	liveResources    = make(map[resourceHandle]string) //handle -> where it was created
+	helpersPackage   = reflect.TypeOf(Object{}).PkgPath()
+)
+
+// turns tracking of created and deleted GL objects on or off
+// only resources created while it is on are tracked
+func EnableResourceTracking(enabled bool) {
+	resourceTracking = enabled
+}
+
+func trackResource(kind resourceKind, id uint32) {
+	if !resourceTracking {
+		return
+	}
+	liveResources[resourceHandle{kind, id}] = creationSite()
+}
+
+func untrackResource(kind resourceKind, id uint32) {
+	delete(liveResources, resourceHandle{kind, id})
+}
+
+// the first caller outside this package, which is where the
+// resource was actually asked for e.g. the Cube call in main
+func creationSite() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	site := "unknown"
+	for {
+		frame, more := frames.Next()
+		site = fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		if !strings.HasPrefix(frame.Function, helpersPackage+".") {
+			break
+		}
+		if !more {
+			break
+		}
+	}
+	return site
+}
+
+// writes every tracked resource that hasn't been deleted yet
+// and returns how many there were
+func ReportLiveResources(w io.Writer) int {
+	lines := make([]string, 0, len(liveResources))
+	for h, site := range liveResources {
+		lines = append(lines, fmt.Sprintf("  %s %d created at %s\n", h.kind, h.id, site))
+	}
+	sort.Strings(lines)
+
+	if len(lines) == 0 {
+		fmt.Fprintln(w, "No live GL resources")
+		return 0
+	}
+	fmt.Fprintf(w, "%d GL resources were never deleted:\n", len(lines))
+	for _, l := range lines {
+		fmt.Fprint(w, l)
+	}
+	return len(lines)
+}
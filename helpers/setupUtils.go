@@ -25,9 +25,9 @@ func SetupFPSWindow(title string, width, height int32) (window *sdl.Window, clea
 	window.GLCreateContext()
 
 	gl.Init()
-	gl.Enable(gl.DEPTH_TEST)
+	backend.Enable(gl.DEPTH_TEST)
 	sdl.SetRelativeMouseMode(true)
-	gl.Enable(gl.CULL_FACE)
+	backend.Enable(gl.CULL_FACE)
 
 	cleanup = func() {
 		sdl.Quit()
@@ -0,0 +1,165 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/*
+Compile and link logs are parsed into one ShaderDiagnostic per message.
+Drivers all format them differently:
+
+	Mesa         0:12(5): error: `normall' undeclared
+	NVIDIA       0(12) : error C1008: undefined variable "normall"
+	AMD/Intel    ERROR: 0:12: 'normall' : undeclared identifier
+
+the leading number is the source string, which the preprocessor's
+#line directives set to the index of the file in ShaderSource.Files.
+*/
+
+type ShaderDiagnostic struct {
+	File     string //empty if the message isn't about a place in the source
+	Line     int    //0 if unknown
+	Column   int    //0 if unknown
+	Severity string //error, warning or info
+	Message  string
+}
+
+func (d ShaderDiagnostic) String() string {
+	location := d.File
+	if d.Line > 0 {
+		location += ":" + strconv.Itoa(d.Line)
+		if d.Column > 0 {
+			location += ":" + strconv.Itoa(d.Column)
+		}
+	}
+	if location == "" {
+		return d.Severity + ": " + d.Message
+	}
+	return location + ": " + d.Severity + ": " + d.Message
+}
+
+// a stage that failed to compile or a program that failed to link
+type ShaderError struct {
+	Stage       string //e.g. fragment, or link for link errors
+	Path        string //the stage's file, or every stage's files for link errors
+	Log         string //exactly what the driver said
+	Diagnostics []ShaderDiagnostic
+
+	sources map[string]string //source that isn't in a file, by name
+}
+
+func (e *ShaderError) Error() string {
+	var b strings.Builder
+	if e.Stage == "link" {
+		fmt.Fprintf(&b, "failed to link program (%s):", e.Path)
+	} else {
+		fmt.Fprintf(&b, "failed to compile %s shader %s:", e.Stage, e.Path)
+	}
+	for _, d := range e.Diagnostics {
+		b.WriteString("\n" + d.String())
+	}
+	return b.String()
+}
+
+// Error with the source line of each diagnostic under it and a caret at its column
+func (e *ShaderError) Pretty() string {
+	var b strings.Builder
+	b.WriteString(strings.SplitN(e.Error(), "\n", 2)[0])
+
+	lines := make(map[string][]string)
+	for _, d := range e.Diagnostics {
+		b.WriteString("\n" + d.String())
+		if d.File == "" || d.Line == 0 {
+			continue
+		}
+
+		fileLines, ok := lines[d.File]
+		if !ok {
+			source, inMemory := e.sources[d.File]
+			if !inMemory {
+				source = readFileOrEmpty(d.File)
+			}
+			fileLines = strings.Split(source, "\n")
+			lines[d.File] = fileLines
+		}
+		if d.Line > len(fileLines) {
+			continue
+		}
+
+		line := fileLines[d.Line-1]
+		gutter := fmt.Sprintf("%5d | ", d.Line)
+		b.WriteString("\n" + gutter + line)
+		if d.Column > 0 && d.Column <= len(line)+1 {
+			//keeps the tabs so the caret lines up however they're shown
+			indent := []rune(line[:d.Column-1])
+			for i, r := range indent {
+				if r != '\t' {
+					indent[i] = ' '
+				}
+			}
+			b.WriteString("\n" + strings.Repeat(" ", len(gutter)-2) + "| " + string(indent) + "^")
+		}
+	}
+	return b.String()
+}
+
+var (
+	//the severity can follow another word e.g. preprocessor error or fatal error
+	mesaLogLine   = regexp.MustCompile(`^(\d+):(\d+)\((\d+)\): (?:\w+ )?(error|warning|info): (.*)$`)
+	nvidiaLogLine = regexp.MustCompile(`^(\d+)\((\d+)\) : (?:\w+ )?(error|warning|info)(?: \w+)?: (.*)$`)
+	amdLogLine    = regexp.MustCompile(`^(ERROR|WARNING|INFO): (\d+):(\d+): (.*)$`)
+	plainLogLine  = regexp.MustCompile(`^(?i)(error|warning|info): (.*)$`)
+)
+
+// splits a driver's info log into diagnostics, source string n is files[n]
+// lines in a format it doesn't know are kept as messages without a place
+func ParseShaderLog(log string, files []string) []ShaderDiagnostic {
+	file := func(index string) string {
+		i, err := strconv.Atoi(index)
+		if err != nil || i >= len(files) {
+			return index
+		}
+		return files[i]
+	}
+	number := func(s string) int {
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+
+	var diagnostics []ShaderDiagnostic
+	for _, line := range strings.Split(log, "\n") {
+		line = strings.TrimRight(line, "\r\x00 ")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		var d ShaderDiagnostic
+		if m := mesaLogLine.FindStringSubmatch(line); m != nil {
+			d = ShaderDiagnostic{file(m[1]), number(m[2]), number(m[3]), m[4], m[5]}
+		} else if m := nvidiaLogLine.FindStringSubmatch(line); m != nil {
+			d = ShaderDiagnostic{file(m[1]), number(m[2]), 0, m[3], m[4]}
+		} else if m := amdLogLine.FindStringSubmatch(line); m != nil {
+			d = ShaderDiagnostic{file(m[2]), number(m[3]), 0, m[1], m[4]}
+		} else if m := plainLogLine.FindStringSubmatch(line); m != nil {
+			d = ShaderDiagnostic{Severity: m[1], Message: m[2]}
+		} else {
+			d = ShaderDiagnostic{Severity: "info", Message: strings.TrimSpace(line)}
+		}
+		d.Severity = strings.ToLower(d.Severity)
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
+
+// the error to log, pretty printed if it's a ShaderError
+func describeShaderError(err error) string {
+	var e *ShaderError
+	if errors.As(err, &e) {
+		return e.Pretty()
+	}
+	return err.Error()
+}
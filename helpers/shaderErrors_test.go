@@ -0,0 +1,86 @@
+package helpers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseShaderLog(t *testing.T) {
+	files := []string{"uber.frag", "include/lighting.glsl"}
+
+	tests := []struct {
+		name string
+		log  string
+		want []ShaderDiagnostic
+	}{
+		{
+			name: "mesa",
+			log:  "0:12(5): error: `normall' undeclared",
+			want: []ShaderDiagnostic{{"uber.frag", 12, 5, "error", "`normall' undeclared"}},
+		},
+		{
+			name: "mesa preprocessor error",
+			log:  "1:1(10): preprocessor error: syntax error, unexpected IDENTIFIER",
+			want: []ShaderDiagnostic{{"include/lighting.glsl", 1, 10, "error", "syntax error, unexpected IDENTIFIER"}},
+		},
+		{
+			name: "mesa warning",
+			log:  "0:3(7): warning: `fog' used uninitialized",
+			want: []ShaderDiagnostic{{"uber.frag", 3, 7, "warning", "`fog' used uninitialized"}},
+		},
+		{
+			name: "nvidia",
+			log:  `0(12) : error C1008: undefined variable "normall"`,
+			want: []ShaderDiagnostic{{"uber.frag", 12, 0, "error", `undefined variable "normall"`}},
+		},
+		{
+			name: "nvidia fatal error",
+			log:  "1(4) : fatal error C9999: out of memory",
+			want: []ShaderDiagnostic{{"include/lighting.glsl", 4, 0, "error", "out of memory"}},
+		},
+		{
+			name: "amd",
+			log:  "ERROR: 0:12: 'normall' : undeclared identifier",
+			want: []ShaderDiagnostic{{"uber.frag", 12, 0, "error", "'normall' : undeclared identifier"}},
+		},
+		{
+			name: "amd warning",
+			log:  "WARNING: 1:20: 'pow' : x < 0",
+			want: []ShaderDiagnostic{{"include/lighting.glsl", 20, 0, "warning", "'pow' : x < 0"}},
+		},
+		{
+			name: "without a place",
+			log:  "error: vertex shader output `Tint' not read by fragment shader",
+			want: []ShaderDiagnostic{{"", 0, 0, "error", "vertex shader output `Tint' not read by fragment shader"}},
+		},
+		{
+			name: "unknown format",
+			log:  "Fragment info\n-------------\n",
+			want: []ShaderDiagnostic{{"", 0, 0, "info", "Fragment info"}, {"", 0, 0, "info", "-------------"}},
+		},
+		{
+			name: "source string that isn't a file",
+			log:  "3:1(1): error: bad",
+			want: []ShaderDiagnostic{{"3", 1, 1, "error", "bad"}},
+		},
+		{
+			name: "several lines",
+			log:  "0:1(1): error: one\r\n\r\n0:2(1): warning: two\x00",
+			want: []ShaderDiagnostic{{"uber.frag", 1, 1, "error", "one"}, {"uber.frag", 2, 1, "warning", "two"}},
+		},
+		{
+			name: "empty",
+			log:  "",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseShaderLog(tt.log, files)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseShaderLog(%q)\n got %#v\nwant %#v", tt.log, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package helpers
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/moltenwolfcub/OpenGLGoLearning/helpers/glsl"
+)
+
+/*
+Shader files are run through a small preprocessor before they go to the driver.
+
+	#include "lighting.glsl"
+
+pastes in another file, found relative to the file including it.
+Defines passed in are added straight after the #version line and
+#line directives keep the driver's line numbers pointing at the
+original files, each file gets its own source string number so
+ParseShaderLog can map compile logs back to them.
+
+Include guards work as normal as the driver's own preprocessor handles
+#ifndef and friends. The includes are resolved by glsl.ReadSource.
+*/
+
+type ShaderSource struct {
+	Code  string
+	Files []string //the source string number in #line is an index into this, Files[0] is the main file
+}
+
+var versionPattern = regexp.MustCompile(`^\s*#\s*version\b`)
+
+// reads the shader at path and resolves its includes
+// defines are added as #define name value, an empty value defines just the name
+// on an error Files still has every file read before it
+func PreprocessShader(path string, defines map[string]string) (ShaderSource, error) {
+	source, err := glsl.ReadSource(path)
+	if err != nil {
+		return ShaderSource{Files: source.Files}, err
+	}
+
+	//#version has to be the first thing in a shader so the defines go after it
+	lines := source.Lines
+	var code []string
+	next := glsl.Line{File: 0, Number: 1} //where the driver thinks the next line is from
+	if len(lines) > 0 && versionPattern.MatchString(lines[0].Text) {
+		code = append(code, lines[0].Text)
+		lines = lines[1:]
+		next.Number++
+	}
+	if len(defines) > 0 {
+		code = append(code, defineLines(defines)...)
+		next.File = -1
+	}
+
+	for _, line := range lines {
+		if line.File != next.File || line.Number != next.Number {
+			code = append(code, lineDirective(line.Number, line.File))
+		}
+		code = append(code, line.Text)
+		next = glsl.Line{File: line.File, Number: line.Number + 1}
+	}
+
+	return ShaderSource{Code: strings.Join(code, "\n") + "\n", Files: source.Files}, nil
+}
+
+func defineLines(defines map[string]string) []string {
+	names := make([]string, 0, len(defines))
+	for name := range defines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := make([]string, len(names))
+	for i, name := range names {
+		lines[i] = strings.TrimSpace("#define " + name + " " + defines[name])
+	}
+	return lines
+}
+
+func lineDirective(line, file int) string {
+	return fmt.Sprintf("#line %d %d", line, file)
+}
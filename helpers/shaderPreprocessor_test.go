@@ -0,0 +1,88 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPreprocessShader(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "include"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "lit.frag"), "#version 330 core\n#include \"include/light.glsl\"\nout vec4 FragColor;\n")
+	writeFile(t, filepath.Join(dir, "include", "light.glsl"), "uniform vec3 lightDir;\n#include \"fog.glsl\"\n")
+	writeFile(t, filepath.Join(dir, "include", "fog.glsl"), "uniform float fogDensity;\n")
+	writeFile(t, filepath.Join(dir, "bare.frag"), "out vec4 FragColor;\n")
+
+	files := []string{
+		filepath.Join(dir, "lit.frag"),
+		filepath.Join(dir, "include", "light.glsl"),
+		filepath.Join(dir, "include", "fog.glsl"),
+	}
+	tests := []struct {
+		name    string
+		file    string
+		defines map[string]string
+		code    string
+		files   []string
+	}{
+		{
+			name:  "includes",
+			file:  "lit.frag",
+			files: files,
+			code: "#version 330 core\n" +
+				"#line 1 1\nuniform vec3 lightDir;\n" +
+				"#line 1 2\nuniform float fogDensity;\n" +
+				"#line 3 0\nout vec4 FragColor;\n",
+		},
+		{
+			name:    "defines",
+			file:    "lit.frag",
+			defines: map[string]string{"LIGHTS": "4", "FOG": ""},
+			files:   files,
+			code: "#version 330 core\n#define FOG\n#define LIGHTS 4\n" +
+				"#line 1 1\nuniform vec3 lightDir;\n" +
+				"#line 1 2\nuniform float fogDensity;\n" +
+				"#line 3 0\nout vec4 FragColor;\n",
+		},
+		{
+			name:    "no version",
+			file:    "bare.frag",
+			defines: map[string]string{"FOG": ""},
+			files:   []string{filepath.Join(dir, "bare.frag")},
+			code:    "#define FOG\n#line 1 0\nout vec4 FragColor;\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := PreprocessShader(filepath.Join(dir, tt.file), tt.defines)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if source.Code != tt.code {
+				t.Errorf("got code\n%s\nwant\n%s", source.Code, tt.code)
+			}
+			if !reflect.DeepEqual(source.Files, tt.files) {
+				t.Errorf("got files %q, want %q", source.Files, tt.files)
+			}
+		})
+	}
+}
+
+func TestPreprocessShaderKeepsFilesOnError(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.frag")
+	writeFile(t, main, "#version 330 core\n#include \"missing.glsl\"\n")
+
+	source, err := PreprocessShader(main, nil)
+	if err == nil {
+		t.Fatal("including a missing file didn't fail")
+	}
+	if !reflect.DeepEqual(source.Files, []string{main}) {
+		t.Errorf("got files %q, want %q so the main file is still watched", source.Files, []string{main})
+	}
+}
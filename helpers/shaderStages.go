@@ -0,0 +1,120 @@
+package helpers
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// not in the 3.3 core bindings
+const (
+	TessControlShader    uint32 = 0x8E88
+	TessEvaluationShader uint32 = 0x8E87
+)
+
+// one file of a program
+type ShaderStage struct {
+	Type uint32 //gl.VERTEX_SHADER, gl.GEOMETRY_SHADER, gl.FRAGMENT_SHADER, gl.COMPUTE_SHADER or a tessellation stage
+	Path string
+}
+
+type stageInfo struct {
+	name      string
+	extension string
+	major     int //the first openGL version with the stage
+	minor     int
+}
+
+// in the order they run in
+var stageInfos = []struct {
+	stage uint32
+	stageInfo
+}{
+	{gl.VERTEX_SHADER, stageInfo{"vertex", ".vert", 2, 0}},
+	{TessControlShader, stageInfo{"tessellation control", ".tesc", 4, 0}},
+	{TessEvaluationShader, stageInfo{"tessellation evaluation", ".tese", 4, 0}},
+	{gl.GEOMETRY_SHADER, stageInfo{"geometry", ".geom", 3, 2}},
+	{gl.FRAGMENT_SHADER, stageInfo{"fragment", ".frag", 2, 0}},
+	{gl.COMPUTE_SHADER, stageInfo{"compute", ".comp", 4, 3}},
+}
+
+func lookupStage(stage uint32) (stageInfo, bool) {
+	for _, s := range stageInfos {
+		if s.stage == stage {
+			return s.stageInfo, true
+		}
+	}
+	return stageInfo{}, false
+}
+
+// e.g. fragment
+func stageName(stage uint32) string {
+	if info, ok := lookupStage(stage); ok {
+		return info.name
+	}
+	return fmt.Sprintf("0x%x", stage)
+}
+
+// works out the stage from the file extension e.g. .geom for a geometry shader
+func StageFromPath(path string) (ShaderStage, error) {
+	ext := filepath.Ext(path)
+	for _, s := range stageInfos {
+		if s.extension == ext {
+			return ShaderStage{Type: s.stage, Path: path}, nil
+		}
+	}
+	return ShaderStage{}, fmt.Errorf("%s: can't tell the shader stage from %q", path, ext)
+}
+
+// checks the stages make a program the context can build
+// either compute on its own or a vertex shader and whichever others
+func validateStages(stages []ShaderStage) error {
+	major, minor := contextVersion()
+	seen := make(map[uint32]bool)
+	for _, s := range stages {
+		info, ok := lookupStage(s.Type)
+		if !ok {
+			return fmt.Errorf("%s: unknown shader stage 0x%x", s.Path, s.Type)
+		}
+		if seen[s.Type] {
+			return fmt.Errorf("%s: the program already has a %s shader", s.Path, info.name)
+		}
+		seen[s.Type] = true
+
+		//an unknown version (0.0) lets the driver decide
+		if major > 0 && (major < info.major || (major == info.major && minor < info.minor)) {
+			return fmt.Errorf("%s: %s shaders need openGL %d.%d but the context is %d.%d",
+				s.Path, info.name, info.major, info.minor, major, minor)
+		}
+	}
+
+	switch {
+	case seen[gl.COMPUTE_SHADER] && len(stages) > 1:
+		return fmt.Errorf("a compute shader can't be linked with other stages")
+	case !seen[gl.COMPUTE_SHADER] && !seen[gl.VERTEX_SHADER]:
+		return fmt.Errorf("the program has no vertex shader")
+	case seen[TessControlShader] && !seen[TessEvaluationShader]:
+		return fmt.Errorf("a tessellation control shader needs an evaluation shader")
+	}
+	return nil
+}
+
+// the version of the current context e.g. 3, 3
+func contextVersion() (int, int) {
+	var major, minor int
+	version := strings.TrimPrefix(GetVersion(), "OpenGL ES ")
+	if _, err := fmt.Sscanf(version, "%d.%d", &major, &minor); err != nil {
+		return 0, 0
+	}
+	return major, minor
+}
+
+func stagePaths(stages []ShaderStage) string {
+	paths := make([]string, len(stages))
+	for i, s := range stages {
+		paths[i] = s.Path
+	}
+	return strings.Join(paths, ", ")
+}
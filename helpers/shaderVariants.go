@@ -0,0 +1,109 @@
+package helpers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+One uber shader compiled with different sets of features turned on, e.g.
+
+	uber := helpers.NewShaderVariants([]string{"uber.vert", "uber.frag"}, "NORMAL_MAP", "FOG")
+	plain := uber.Get()
+	fancy := uber.Get("NORMAL_MAP", "FOG")
+
+each feature is passed to the shader as a #define. A variant is built the
+first time it's asked for and then kept. When any of the files change every
+variant that has been built is reloaded at the same time.
+*/
+
+type ShaderVariants struct {
+	stages   []ShaderStage
+	features map[string]bool
+	variants map[string]*Shader //sorted features -> variant
+}
+
+// the stages are worked out from the file extensions like NewShaderFromFiles
+// features lists every define Get will accept
+func NewShaderVariants(paths []string, features ...string) *ShaderVariants {
+	v := ShaderVariants{
+		features: make(map[string]bool),
+		variants: make(map[string]*Shader),
+	}
+	for _, path := range paths {
+		stage, err := StageFromPath(path)
+		if err != nil {
+			panic(err)
+		}
+		v.stages = append(v.stages, stage)
+	}
+	for _, f := range features {
+		v.features[f] = true
+	}
+	return &v
+}
+
+// the variant with exactly these features, building it if it hasn't been
+// a feature can be NAME or NAME=value, panics if NAME isn't one of the features
+func (v *ShaderVariants) Get(features ...string) *Shader {
+	defines := make(map[string]string)
+	for _, f := range features {
+		name, value, _ := strings.Cut(f, "=")
+		if !v.features[name] {
+			panic(fmt.Errorf("shader (%s) has no feature %s", stagePaths(v.stages), name))
+		}
+		defines[name] = value
+	}
+
+	key := variantKey(defines)
+	if s, ok := v.variants[key]; ok {
+		return s
+	}
+	s := NewShaderFromStages(v.stages, defines)
+	s.group = v
+	v.variants[key] = s
+	return s
+}
+
+// e.g. FOG,NORMAL_MAP=2
+func variantKey(defines map[string]string) string {
+	keys := make([]string, 0, len(defines))
+	for name, value := range defines {
+		if value != "" {
+			name += "=" + value
+		}
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// every variant that has been built
+func (v *ShaderVariants) Built() []*Shader {
+	keys := make([]string, 0, len(v.variants))
+	for key := range v.variants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	shaders := make([]*Shader, len(keys))
+	for i, key := range keys {
+		shaders[i] = v.variants[key]
+	}
+	return shaders
+}
+
+func (v *ShaderVariants) remove(s *Shader) {
+	key := variantKey(s.defines)
+	if v.variants[key] == s {
+		delete(v.variants, key)
+	}
+}
+
+// frees every variant, they can't be used afterwards
+func (v *ShaderVariants) Delete() {
+	for _, s := range v.Built() {
+		s.Delete() //takes it out of v.variants
+	}
+}
@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDeletedVariantIsNotReloaded(t *testing.T) {
+	b := useRecordingBackend(t)
+	dir := t.TempDir()
+	vertPath, fragPath := filepath.Join(dir, "uber.vert"), filepath.Join(dir, "uber.frag")
+	writeFile(t, vertPath, testVertSource)
+	writeFile(t, fragPath, testFragSource)
+
+	uber := NewShaderVariants([]string{vertPath, fragPath}, "FOG")
+	t.Cleanup(uber.Delete)
+	plain := uber.Get()
+	fog := uber.Get("FOG")
+
+	fog.Delete()
+	if built := uber.Built(); len(built) != 1 || built[0] != plain {
+		t.Fatalf("got %d built variants after deleting one, want just the plain one", len(built))
+	}
+
+	created := b.CallCount("CreateProgram")
+	old := plain.id
+	writeFile(t, vertPath, testVertSource+"\n")
+	//make sure the watcher sees a new time even on coarse filesystem clocks
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(vertPath, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for plain.id == old {
+		if time.Now().After(deadline) {
+			t.Fatal("the plain variant wasn't reloaded")
+		}
+		time.Sleep(50 * time.Millisecond)
+		ReloadChangedShaders()
+	}
+
+	if n := b.CallCount("CreateProgram") - created; n != 1 {
+		t.Errorf("made %d programs on reload, want 1", n)
+	}
+	if fog.id != 0 {
+		t.Errorf("deleted variant got program %d", fog.id)
+	}
+}
@@ -2,88 +2,195 @@ package helpers
 
 import (
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
-	"github.com/go-gl/mathgl/mgl32"
 )
 
 type ProgramID uint32
 type ShaderID uint32
 
+// panics if a stage fails to compile or the program fails to link
 func CreateProgram(vertPath string, fragPath string) ProgramID {
-	vert := LoadShader(vertPath, gl.VERTEX_SHADER)
-	frag := LoadShader(fragPath, gl.FRAGMENT_SHADER)
-
-	shaderProgram := gl.CreateProgram()
-	gl.AttachShader(shaderProgram, uint32(vert))
-	gl.AttachShader(shaderProgram, uint32(frag))
-	gl.LinkProgram(shaderProgram)
-	var success int32
-	gl.GetProgramiv(shaderProgram, gl.LINK_STATUS, &success)
+	return CreateProgramFromStages(
+		ShaderStage{gl.VERTEX_SHADER, vertPath},
+		ShaderStage{gl.FRAGMENT_SHADER, fragPath},
+	)
+}
+
+// panics if a stage fails to compile or the program fails to link
+func CreateProgramFromStages(stages ...ShaderStage) ProgramID {
+	id, _, err := buildProgram(stages, nil)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// also returns every file the program was built from, includes and all
+// which is as many as were read before the error if it fails
+func buildProgram(stages []ShaderStage, defines map[string]string) (ProgramID, []string, error) {
+	if err := validateStages(stages); err != nil {
+		return 0, nil, err
+	}
+
+	var shaders []ShaderID
+	var files []string
+	for _, stage := range stages {
+		shader, stageFiles, err := loadShader(stage.Path, stage.Type, defines)
+		files = append(files, stageFiles...)
+		if err != nil {
+			for _, compiled := range shaders {
+				backend.DeleteShader(uint32(compiled))
+			}
+			return 0, files, err
+		}
+		shaders = append(shaders, shader)
+	}
+
+	id, err := linkProgram(stagePaths(stages), shaders...)
+	return id, files, err
+}
+
+// links the shaders into a program and deletes them, name is for errors
+func linkProgram(name string, shaders ...ShaderID) (ProgramID, error) {
+	shaderProgram := backend.CreateProgram()
+	for _, shader := range shaders {
+		backend.AttachShader(shaderProgram, uint32(shader))
+	}
+	backend.LinkProgram(shaderProgram)
+	for _, shader := range shaders {
+		backend.DeleteShader(uint32(shader)) //only flagged for deletion until the program is deleted
+	}
+
+	success := backend.GetProgramiv(shaderProgram, gl.LINK_STATUS)
 	if success == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(shaderProgram, gl.INFO_LOG_LENGTH, &logLength)
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(shaderProgram, logLength, nil, gl.Str(log))
-		panic("Failed to link program:\n" + log)
+		logLength := backend.GetProgramiv(shaderProgram, gl.INFO_LOG_LENGTH)
+		log := backend.GetProgramInfoLog(shaderProgram, logLength)
+		backend.DeleteProgram(shaderProgram)
+		return 0, &ShaderError{Stage: "link", Path: name, Log: log, Diagnostics: ParseShaderLog(log, nil)}
 	}
-	gl.DeleteShader(uint32(vert))
-	gl.DeleteShader(uint32(frag))
 
-	return ProgramID(shaderProgram)
+	trackResource(programResource, shaderProgram)
+	noteProgramLinked(ProgramID(shaderProgram))
+	reflectUniforms(ProgramID(shaderProgram))
+	bindUniformBlocks(ProgramID(shaderProgram))
+	return ProgramID(shaderProgram), nil
 }
 
+// compiles the file at path after resolving its includes
 func LoadShader(path string, shaderType uint32) ShaderID {
-	shaderFile, err := os.ReadFile(path)
+	shaderId, _, err := loadShader(path, shaderType, nil)
 	if err != nil {
 		panic(err)
 	}
-
-	shaderId := CreateShader(string(shaderFile), shaderType)
 	return shaderId
 }
 
+func loadShader(path string, shaderType uint32, defines map[string]string) (ShaderID, []string, error) {
+	source, err := PreprocessShader(path, defines)
+	if err != nil {
+		return 0, source.Files, err
+	}
+
+	shaderId, log, ok := compileShader(source.Code, shaderType)
+	if !ok {
+		return 0, source.Files, &ShaderError{
+			Stage:       stageName(shaderType),
+			Path:        path,
+			Log:         log,
+			Diagnostics: ParseShaderLog(log, source.Files),
+		}
+	}
+	return shaderId, source.Files, nil
+}
+
+// compiles the source as is, without the preprocessor
 func CreateShader(shaderSource string, shaderType uint32) ShaderID {
-	shaderId := gl.CreateShader(shaderType)
-	shaderSource += "\x00"
-	csource, free := gl.Strs(shaderSource)
-	gl.ShaderSource(shaderId, 1, csource, nil)
-	free()
-	gl.CompileShader(shaderId)
-	var status int32
-	gl.GetShaderiv(shaderId, gl.COMPILE_STATUS, &status)
+	shaderId, log, ok := compileShader(shaderSource, shaderType)
+	if !ok {
+		const name = "<source>"
+		panic(&ShaderError{
+			Stage:       stageName(shaderType),
+			Path:        name,
+			Log:         log,
+			Diagnostics: ParseShaderLog(log, []string{name}),
+			sources:     map[string]string{name: shaderSource},
+		})
+	}
+	return shaderId
+}
+
+func compileShader(shaderSource string, shaderType uint32) (ShaderID, string, bool) {
+	shaderId := backend.CreateShader(shaderType)
+	backend.ShaderSource(shaderId, shaderSource)
+	backend.CompileShader(shaderId)
+	status := backend.GetShaderiv(shaderId, gl.COMPILE_STATUS)
 	if status == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(shaderId, gl.INFO_LOG_LENGTH, &logLength)
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(shaderId, logLength, nil, gl.Str(log))
-		panic("Failed to compile shader:\n" + log)
+		logLength := backend.GetShaderiv(shaderId, gl.INFO_LOG_LENGTH)
+		log := backend.GetShaderInfoLog(shaderId, logLength)
+		backend.DeleteShader(shaderId)
+		return 0, log, false
 	}
-	return ShaderID(shaderId)
+	return ShaderID(shaderId), "", true
 }
 
 type Shader struct {
-	id          ProgramID
-	vertPath    string
-	vertModTime time.Time
-	fragPath    string
-	fragModTime time.Time
+	id      ProgramID
+	stages  []ShaderStage
+	defines map[string]string
+	files   []string        //every file the program was built from, see shaderWatcher
+	broken  bool            //id is the shared error program, see ShowShaderErrors
+	warned  map[string]bool //uniforms that have had a warning, see uniforms.go
+	group   *ShaderVariants //reloaded with the other variants, nil if it isn't one
 }
 
 func NewShader(vertPath string, fragPath string) *Shader {
-	id := CreateProgram(vertPath, fragPath)
+	return NewShaderWithDefines(vertPath, fragPath, nil)
+}
+
+// the defines are added to both stages, see PreprocessShader
+func NewShaderWithDefines(vertPath string, fragPath string, defines map[string]string) *Shader {
+	return NewShaderFromStages([]ShaderStage{
+		{gl.VERTEX_SHADER, vertPath},
+		{gl.FRAGMENT_SHADER, fragPath},
+	}, defines)
+}
+
+// builds a program from one file per stage, each stage is worked out
+// from the file's extension (.vert .tesc .tese .geom .frag .comp)
+func NewShaderFromFiles(paths ...string) *Shader {
+	stages := make([]ShaderStage, len(paths))
+	for i, path := range paths {
+		stage, err := StageFromPath(path)
+		if err != nil {
+			panic(err)
+		}
+		stages[i] = stage
+	}
+	return NewShaderFromStages(stages, nil)
+}
 
+// the defines are added to every stage and every stage's file is watched
+// panics if the program can't be built unless ShowShaderErrors is on
+func NewShaderFromStages(stages []ShaderStage, defines map[string]string) *Shader {
 	s := Shader{
-		id:       id,
-		vertPath: vertPath,
-		fragPath: fragPath,
+		stages:  stages,
+		defines: defines,
+	}
 
-		vertModTime: getFileModTime(vertPath),
-		fragModTime: getFileModTime(fragPath),
+	id, files, err := buildProgram(stages, defines)
+	switch {
+	case err == nil:
+		s.id = id
+	case showErrorShader:
+		s.logError(err)
+		s.showError()
+	default:
+		panic(err)
 	}
+	s.watch(files)
 
 	return &s
 }
@@ -92,54 +199,174 @@ func (s *Shader) Use() {
 	UseProgram(s.id)
 }
 
-func (s *Shader) CheckShadersForChanges() {
-	vertModTime := getFileModTime(s.vertPath)
-	fragModTime := getFileModTime(s.fragPath)
-	if v, f := !vertModTime.Equal(s.vertModTime), !fragModTime.Equal(s.fragModTime); v || f {
-		if v {
-			fmt.Printf("A vertex shader file has been modified: %s\n", s.vertPath)
-			s.vertModTime = vertModTime
+// how often the shared watcher checks shader files and how long
+// they have to stop changing for before their shaders are reloaded
+const (
+	shaderPollInterval = 250 * time.Millisecond
+	shaderDebounce     = 100 * time.Millisecond
+)
+
+// watches the files of every Shader, made by the first one
+// and stopped when the last one is deleted
+var shaderWatcher *FileWatcher[*Shader]
+
+// rebuilds every shader whose files, including the ones they include, have changed
+// call it once a frame on the GL thread, the files are checked in the background
+// a shader whose new version doesn't build keeps its old program and logs the error
+func ReloadChangedShaders() {
+	if shaderWatcher == nil {
+		return
+	}
+
+	var changed []*Shader
+	seen := make(map[*Shader]bool)
+	add := func(s *Shader) {
+		if !seen[s] {
+			seen[s] = true
+			changed = append(changed, s)
+		}
+	}
+	for s, ok := shaderWatcher.Next(); ok; s, ok = shaderWatcher.Next() {
+		if s.group == nil {
+			add(s)
+			continue
+		}
+		for _, variant := range s.group.Built() {
+			add(variant)
+		}
+	}
+
+	for _, s := range changed {
+		s.reload()
+	}
+}
+
+func (s *Shader) reload() {
+	id, files, err := buildProgram(s.stages, s.defines)
+	if err != nil {
+		//keep watching the old files as well so fixing any of them retries
+		s.watch(append(files, s.files...))
+
+		s.logError(err)
+		if showErrorShader {
+			s.showError()
 		}
-		if f {
-			fmt.Printf("A fragment shader file has been modified: %s\n", s.fragPath)
-			s.fragModTime = fragModTime
+		return
+	}
+
+	s.release()
+	s.id = id
+	s.warned = nil
+	s.watch(files) //the includes might have changed too
+	fmt.Printf("Reloaded shader (%s)\n", s.Name())
+}
+
+// the stage files and defines, for messages
+func (s *Shader) Name() string {
+	if len(s.defines) > 0 {
+		return stagePaths(s.stages) + " [" + variantKey(s.defines) + "]"
+	}
+	return stagePaths(s.stages)
+}
+
+func (s *Shader) logError(err error) {
+	fmt.Printf("Shader (%s) failed to build, it will be retried when its files change:\n%s\n", s.Name(), describeShaderError(err))
+}
+
+func (s *Shader) watch(files []string) {
+	seen := make(map[string]bool)
+	unique := make([]string, 0, len(files))
+	for _, path := range files {
+		if !seen[path] {
+			seen[path] = true
+			unique = append(unique, path)
 		}
-		id := CreateProgram(s.vertPath, s.fragPath)
+	}
+	s.files = unique
 
-		gl.DeleteProgram(uint32(s.id))
-		s.id = id
+	if shaderWatcher == nil {
+		shaderWatcher = NewFileWatcher[*Shader](shaderPollInterval, shaderDebounce)
 	}
+	shaderWatcher.Watch(s, s.files)
 }
 
-func (s *Shader) SetFloat(name string, value float32) {
-	name_cstr := gl.Str(name + "\x00")
-	loc := gl.GetUniformLocation(uint32(s.id), name_cstr)
+func (s *Shader) unwatch() {
+	if shaderWatcher == nil {
+		return
+	}
+	shaderWatcher.Unwatch(s)
+	if shaderWatcher.Len() == 0 {
+		shaderWatcher.Stop()
+		shaderWatcher = nil
+	}
+}
 
-	gl.Uniform1f(loc, value)
+// swaps the program for the magenta error program
+func (s *Shader) showError() {
+	if s.broken {
+		return
+	}
+	instanced := s.usesInstancing()
+	s.release()
+	s.id = acquireErrorProgram(instanced)
+	s.broken = true
 }
-func (s *Shader) SetMatrix4(name string, value mgl32.Mat4) {
-	name_cstr := gl.Str(name + "\x00")
-	loc := gl.GetUniformLocation(uint32(s.id), name_cstr)
 
-	m4 := [16]float32(value)
-	gl.UniformMatrix4fv(loc, 1, false, &m4[0])
+// if the shader reads instance data, going by the working program if it has one
+// otherwise by its INSTANCED define or its vertex shader's source
+func (s *Shader) usesInstancing() bool {
+	if s.id != 0 && !s.broken {
+		for _, input := range activeInputs(s.id) {
+			if _, ok := instanceInputs[input.name]; ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	if _, ok := s.defines["INSTANCED"]; ok {
+		return true
+	}
+	for _, stage := range s.stages {
+		if stage.Type == gl.VERTEX_SHADER && strings.Contains(readFileOrEmpty(stage.Path), "aInstanceModel") {
+			return true
+		}
+	}
+	return false
 }
-func (s *Shader) SetVec3(name string, value mgl32.Vec3) {
-	name_cstr := gl.Str(name + "\x00")
-	loc := gl.GetUniformLocation(uint32(s.id), name_cstr)
 
-	v3 := [3]float32(value)
-	gl.Uniform3fv(loc, 1, &v3[0])
+// frees the program unless it's the shared error program
+func (s *Shader) release() {
+	if s.broken {
+		releaseErrorProgram(s.id)
+		s.broken = false
+	} else {
+		DeleteProgram(s.id)
+	}
+	s.id = 0
 }
 
-func getFileModTime(path string) time.Time {
-	file, err := os.Stat(path)
-	if err != nil {
-		panic(err)
+// frees the program, the shader can't be used afterwards
+// a variant is taken out of its ShaderVariants so it isn't reloaded
+func (s *Shader) Delete() {
+	s.release()
+	s.unwatch()
+	if s.group != nil {
+		s.group.remove(s)
+		s.group = nil
 	}
-	return file.ModTime()
 }
 
 func UseProgram(id ProgramID) {
-	gl.UseProgram(uint32(id))
+	backend.UseProgram(uint32(id))
+}
+
+func DeleteProgram(id ProgramID) {
+	if id == 0 {
+		return
+	}
+	backend.DeleteProgram(uint32(id))
+	untrackResource(programResource, uint32(id))
+	delete(programLinks, id)
+	delete(programUniforms, id)
 }
@@ -0,0 +1,85 @@
+package helpers
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+/*
+Tangent space generation for normal mapping.
+Like the normal generation this is plain go so it can run without openGL.
+
+Tangents follow the same conventions as MikkTSpace: they are averaged
+per vertex weighted by the corner angle, made orthogonal to the normal
+and the W component stores the handedness so that
+bitangent = W * cross(normal, tangent)
+*/
+
+// returns one tangent per vertex, indices may be nil for unindexed triangles
+func GenerateTangents(positions []mgl32.Vec3, uvs []mgl32.Vec2, normals []mgl32.Vec3, indices []uint32) []mgl32.Vec4 {
+	if indices == nil {
+		indices = make([]uint32, len(positions))
+		for i := range indices {
+			indices[i] = uint32(i)
+		}
+	}
+
+	tangentSums := make([]mgl32.Vec3, len(positions))
+	bitangentSums := make([]mgl32.Vec3, len(positions))
+
+	for tri := 0; tri+2 < len(indices); tri += 3 {
+		i1, i2, i3 := indices[tri], indices[tri+1], indices[tri+2]
+		p1, p2, p3 := positions[i1], positions[i2], positions[i3]
+		uv1, uv2, uv3 := uvs[i1], uvs[i2], uvs[i3]
+
+		edge1, edge2 := p2.Sub(p1), p3.Sub(p1)
+		duv1, duv2 := uv2.Sub(uv1), uv3.Sub(uv1)
+
+		det := duv1.X()*duv2.Y() - duv2.X()*duv1.Y()
+		if mgl32.Abs(det) < 1e-12 {
+			continue //the UVs don't span an area so there's no direction to follow
+		}
+		r := 1 / det
+
+		tangent := edge1.Mul(duv2.Y()).Sub(edge2.Mul(duv1.Y())).Mul(r)
+		bitangent := edge2.Mul(duv1.X()).Sub(edge1.Mul(duv2.X())).Mul(r)
+
+		corners := [3][3]mgl32.Vec3{{p1, p2, p3}, {p2, p3, p1}, {p3, p1, p2}}
+		for i, index := range [3]uint32{i1, i2, i3} {
+			weight := cornerAngle(corners[i][0], corners[i][1], corners[i][2])
+			tangentSums[index] = tangentSums[index].Add(tangent.Mul(weight))
+			bitangentSums[index] = bitangentSums[index].Add(bitangent.Mul(weight))
+		}
+	}
+
+	tangents := make([]mgl32.Vec4, len(positions))
+	for v := range tangents {
+		n := normals[v]
+
+		//gram-schmidt so the tangent is perpendicular to the normal
+		t := safeNormalize(tangentSums[v].Sub(n.Mul(n.Dot(tangentSums[v]))))
+		if t == (mgl32.Vec3{}) {
+			t = anyPerpendicular(n)
+		}
+
+		handedness := float32(1)
+		if n.Cross(t).Dot(bitangentSums[v]) < 0 {
+			handedness = -1
+		}
+		tangents[v] = t.Vec4(handedness)
+	}
+	return tangents
+}
+
+// the bitangent a shader would rebuild from a normal and a generated tangent
+func Bitangent(normal mgl32.Vec3, tangent mgl32.Vec4) mgl32.Vec3 {
+	return normal.Cross(tangent.Vec3()).Mul(tangent.W())
+}
+
+// used when the UVs don't give a tangent so the TBN matrix is still valid
+func anyPerpendicular(n mgl32.Vec3) mgl32.Vec3 {
+	axis := mgl32.Vec3{1, 0, 0}
+	if mgl32.Abs(n.X()) > 0.9 {
+		axis = mgl32.Vec3{0, 1, 0}
+	}
+	return safeNormalize(axis.Sub(n.Mul(n.Dot(axis))))
+}
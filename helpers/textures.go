@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"image"
 	"image/png"
 	"os"
 
@@ -21,13 +22,19 @@ func LoadTexture(filename string) TextureID {
 		panic(err)
 	}
 
-	w := img.Bounds().Max.X
-	h := img.Bounds().Max.Y
+	return TextureFromImage(img)
+}
+
+// uploads an already decoded image as a repeating, mipmapped texture
+func TextureFromImage(img image.Image) TextureID {
+	bounds := img.Bounds()
+	w := bounds.Dx()
+	h := bounds.Dy()
 
 	pixels := make([]byte, w*h*4)
 	i := 0
-	for y := 0; y < h; y++ {
-		for x := 0; x < w; x++ {
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
 			r, g, b, a := img.At(x, y).RGBA()
 			pixels[i] = byte(r / 256)
 			i++
@@ -41,24 +48,41 @@ func LoadTexture(filename string) TextureID {
 	}
 
 	texture := GenBindTexture()
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(w), int32(h), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pixels))
-	gl.GenerateMipmap(gl.TEXTURE_2D)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	backend.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(w), int32(h), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pixels))
+	backend.GenerateMipmap(gl.TEXTURE_2D)
 	return texture
 }
 
 // generates a new nexture ID and binds it to gl.TEXTURE_2D
 func GenBindTexture() TextureID {
-	var textureId uint32
-	gl.GenTextures(1, &textureId)
-	gl.BindTexture(gl.TEXTURE_2D, textureId)
+	textureId := backend.GenTexture()
+	backend.BindTexture(gl.TEXTURE_2D, textureId)
+	trackResource(textureResource, textureId)
 	return TextureID(textureId)
 }
 
+func (t TextureID) Delete() {
+	if t == 0 {
+		return
+	}
+	textureId := uint32(t)
+	backend.DeleteTexture(textureId)
+	untrackResource(textureResource, textureId)
+}
+
 // binds a texture to gl.TEXTURE_2D from its texture id
 func BindTexture(id TextureID) {
-	gl.BindTexture(gl.TEXTURE_2D, uint32(id))
+	backend.BindTexture(gl.TEXTURE_2D, uint32(id))
+}
+
+// binds a texture to gl.TEXTURE_2D on the given texture unit
+// e.g. unit 1 for a sampler uniform set to 1
+func BindTextureUnit(unit uint32, id TextureID) {
+	backend.ActiveTexture(gl.TEXTURE0 + unit)
+	backend.BindTexture(gl.TEXTURE_2D, uint32(id))
+	backend.ActiveTexture(gl.TEXTURE0)
 }
@@ -0,0 +1,254 @@
+package helpers
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+/*
+Uniform buffers shared by every program, packed from a Go struct
+using the std140 rules. The struct's fields have to be in the same
+order as the block's members e.g.
+
+	type Camera struct {
+		Proj    mgl32.Mat4
+		View    mgl32.Mat4
+		ViewPos mgl32.Vec3
+	}
+
+for
+
+	layout (std140) uniform Camera {
+		mat4 proj;
+		mat4 view;
+		vec3 viewPos;
+	};
+*/
+
+// one copy from the Go value into the packed block
+type std140Copy struct {
+	src    uintptr //offset in the Go value
+	dst    int     //offset in the block
+	size   int
+	isBool bool //a Go bool is 1 byte but a glsl bool is 4
+}
+
+type std140Layout struct {
+	copies []std140Copy
+	end    int //where the last member ends
+	size   int //end rounded up to a vec4
+}
+
+var (
+	vec2Type = reflect.TypeOf(mgl32.Vec2{})
+	vec3Type = reflect.TypeOf(mgl32.Vec3{})
+	vec4Type = reflect.TypeOf(mgl32.Vec4{})
+
+	matrixColumns = map[reflect.Type]int{
+		reflect.TypeOf(mgl32.Mat2{}): 2,
+		reflect.TypeOf(mgl32.Mat3{}): 3,
+		reflect.TypeOf(mgl32.Mat4{}): 4,
+	}
+)
+
+func std140LayoutOf(t reflect.Type) (std140Layout, error) {
+	l := std140Layout{}
+	if t == nil || t.Kind() != reflect.Struct {
+		return l, fmt.Errorf("%v isn't a struct", t)
+	}
+
+	end, err := l.addFields(t, 0, 0)
+	if err != nil {
+		return l, err
+	}
+	l.end = end
+	l.size = roundUp(end, 16)
+	return l, nil
+}
+
+func roundUp(n, multiple int) int {
+	return (n + multiple - 1) / multiple * multiple
+}
+
+// the base alignment of a member of type t
+func std140Align(t reflect.Type) int {
+	switch {
+	case t == vec2Type:
+		return 8
+	case t == vec3Type || t == vec4Type:
+		return 16
+	case t.Kind() == reflect.Array || t.Kind() == reflect.Struct:
+		return 16 //includes matrices, they are arrays of vec4 aligned columns
+	default:
+		return 4
+	}
+}
+
+// adds the struct's fields starting at dst and returns where the last one ends
+func (l *std140Layout) addFields(t reflect.Type, src uintptr, dst int) (int, error) {
+	offset := dst
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		offset = roundUp(offset, std140Align(field.Type))
+
+		size, err := l.add(field.Type, src+field.Offset, offset)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", field.Name, err)
+		}
+		offset += size
+	}
+	return offset, nil
+}
+
+// adds a member of type t at an already aligned dst and returns its size
+func (l *std140Layout) add(t reflect.Type, src uintptr, dst int) (int, error) {
+	if t == vec2Type || t == vec3Type || t == vec4Type {
+		size := int(t.Size())
+		l.copies = append(l.copies, std140Copy{src: src, dst: dst, size: size})
+		return size, nil
+	}
+	if n, ok := matrixColumns[t]; ok {
+		//every column is padded out to a vec4
+		for col := 0; col < n; col++ {
+			l.copies = append(l.copies, std140Copy{src: src + uintptr(col*n*4), dst: dst + col*16, size: n * 4})
+		}
+		return n * 16, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Float32, reflect.Int32, reflect.Uint32:
+		l.copies = append(l.copies, std140Copy{src: src, dst: dst, size: 4})
+		return 4, nil
+	case reflect.Bool:
+		l.copies = append(l.copies, std140Copy{src: src, dst: dst, size: 1, isBool: true})
+		return 4, nil
+	case reflect.Array:
+		//each element is padded out to a vec4
+		elem := t.Elem()
+		var stride int
+		for i := 0; i < t.Len(); i++ {
+			size, err := l.add(elem, src+uintptr(i)*elem.Size(), dst+i*stride)
+			if err != nil {
+				return 0, fmt.Errorf("[%d]: %w", i, err)
+			}
+			stride = roundUp(size, 16)
+		}
+		return t.Len() * stride, nil
+	case reflect.Struct:
+		end, err := l.addFields(t, src, dst)
+		if err != nil {
+			return 0, err
+		}
+		return roundUp(end-dst, 16), nil
+	default:
+		return 0, fmt.Errorf("%v can't go in a uniform block", t)
+	}
+}
+
+// writes the value into dst in std140 layout
+func (l std140Layout) pack(value unsafe.Pointer, dst []byte) {
+	for _, c := range l.copies {
+		src := unsafe.Slice((*byte)(unsafe.Add(value, c.src)), c.size)
+		if c.isBool {
+			var b uint32
+			if src[0] != 0 {
+				b = 1
+			}
+			binary.NativeEndian.PutUint32(dst[c.dst:], b)
+			continue
+		}
+		copy(dst[c.dst:], src)
+	}
+}
+
+// a binding point that every program's block with this name is attached to
+type uniformBlock struct {
+	binding uint32
+	minSize int //the block in the shader must be between these sizes
+	maxSize int
+}
+
+var uniformBlocks = make(map[string]uniformBlock)
+
+func registerUniformBlock(name string, layout std140Layout) uint32 {
+	block, ok := uniformBlocks[name]
+	if !ok {
+		block.binding = uint32(len(uniformBlocks))
+	}
+	block.minSize, block.maxSize = layout.end, layout.size
+	uniformBlocks[name] = block
+
+	//programs linked before the buffer was made
+	for program := range programLinks {
+		bindUniformBlock(program, name, block)
+	}
+	return block.binding
+}
+
+// attaches every block with a UniformBuffer to the program's binding points
+func bindUniformBlocks(program ProgramID) {
+	for name, block := range uniformBlocks {
+		bindUniformBlock(program, name, block)
+	}
+}
+
+func bindUniformBlock(program ProgramID, name string, block uniformBlock) {
+	index := backend.GetUniformBlockIndex(uint32(program), name)
+	if index == gl.INVALID_INDEX {
+		return //this program doesn't use the block
+	}
+
+	size := backend.GetActiveUniformBlockiv(uint32(program), index, gl.UNIFORM_BLOCK_DATA_SIZE)
+	if int(size) < block.minSize || int(size) > block.maxSize {
+		fmt.Printf("Uniform block %s is %d bytes in program %d but %d bytes from its Go struct\n", name, size, program, block.maxSize)
+	}
+
+	backend.UniformBlockBinding(uint32(program), index, block.binding)
+}
+
+// a uniform block shared by every program that declares it
+type UniformBuffer[T any] struct {
+	block   string
+	binding uint32
+	layout  std140Layout
+	buffer  *DynamicBuffer
+	data    []byte
+}
+
+// panics if T can't be packed into a block
+func NewUniformBuffer[T any](block string) *UniformBuffer[T] {
+	var v T
+	layout, err := std140LayoutOf(reflect.TypeOf(v))
+	if err != nil {
+		panic(fmt.Errorf("uniform block %s: %w", block, err))
+	}
+
+	u := UniformBuffer[T]{
+		block:   block,
+		binding: registerUniformBlock(block, layout),
+		layout:  layout,
+		buffer:  NewDynamicBuffer(gl.UNIFORM_BUFFER, layout.size, gl.DYNAMIC_DRAW),
+		data:    make([]byte, layout.size),
+	}
+	backend.BindBufferBase(gl.UNIFORM_BUFFER, u.binding, uint32(u.buffer.ID()))
+	return &u
+}
+
+// uploads a new value, every program sees it from the next draw
+func (u *UniformBuffer[T]) Set(value T) {
+	u.layout.pack(unsafe.Pointer(&value), u.data)
+	UpdateBuffer(u.buffer, 0, u.data)
+}
+
+func (u *UniformBuffer[T]) Binding() uint32 {
+	return u.binding
+}
+
+func (u *UniformBuffer[T]) Delete() {
+	u.buffer.Delete()
+}
@@ -0,0 +1,183 @@
+package helpers
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestStd140LayoutOf(t *testing.T) {
+	type inner struct {
+		X float32
+	}
+	type element struct {
+		V mgl32.Vec3
+		F float32
+	}
+
+	tests := []struct {
+		name   string
+		value  any
+		copies [][2]int //where each member lands and how many bytes it copies
+		end    int
+		size   int
+		err    string
+	}{
+		{
+			//a float fits in the padding after a vec3
+			name: "vec3 then float",
+			value: struct {
+				A mgl32.Vec3
+				B float32
+			}{},
+			copies: [][2]int{{0, 12}, {12, 4}},
+			end:    16,
+			size:   16,
+		},
+		{
+			name: "vec2 alignment",
+			value: struct {
+				A float32
+				V mgl32.Vec2
+				B float32
+			}{},
+			copies: [][2]int{{0, 4}, {8, 8}, {16, 4}},
+			end:    20,
+			size:   32,
+		},
+		{
+			name: "float array",
+			value: struct {
+				A [3]float32
+				B float32
+			}{},
+			copies: [][2]int{{0, 4}, {16, 4}, {32, 4}, {48, 4}},
+			end:    52,
+			size:   64,
+		},
+		{
+			name: "mat4",
+			value: struct {
+				A float32
+				M mgl32.Mat4
+			}{},
+			copies: [][2]int{{0, 4}, {16, 16}, {32, 16}, {48, 16}, {64, 16}},
+			end:    80,
+			size:   80,
+		},
+		{
+			//each column is padded out to a vec4
+			name: "mat3",
+			value: struct {
+				M mgl32.Mat3
+				B float32
+			}{},
+			copies: [][2]int{{0, 12}, {16, 12}, {32, 12}, {48, 4}},
+			end:    52,
+			size:   64,
+		},
+		{
+			name: "nested struct",
+			value: struct {
+				A float32
+				S inner
+				B float32
+			}{},
+			copies: [][2]int{{0, 4}, {16, 4}, {32, 4}},
+			end:    36,
+			size:   48,
+		},
+		{
+			name:   "array of structs",
+			value:  struct{ E [2]element }{},
+			copies: [][2]int{{0, 12}, {12, 4}, {16, 12}, {28, 4}},
+			end:    32,
+			size:   32,
+		},
+		{
+			name: "bool",
+			value: struct {
+				A bool
+				B float32
+			}{},
+			copies: [][2]int{{0, 1}, {4, 4}},
+			end:    8,
+			size:   16,
+		},
+		{
+			name:  "unsupported type",
+			value: struct{ A float64 }{},
+			err:   "A: float64 can't go in a uniform block",
+		},
+		{
+			name:  "not a struct",
+			value: mgl32.Mat4{},
+			err:   "mgl32.Mat4 isn't a struct",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := std140LayoutOf(reflect.TypeOf(tt.value))
+			if got := errorText(err); got != tt.err {
+				t.Fatalf("got error %q, want %q", got, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			var copies [][2]int
+			for _, c := range l.copies {
+				copies = append(copies, [2]int{c.dst, c.size})
+			}
+			if !reflect.DeepEqual(copies, tt.copies) {
+				t.Errorf("got copies %v, want %v", copies, tt.copies)
+			}
+			if l.end != tt.end || l.size != tt.size {
+				t.Errorf("got end %d and size %d, want %d and %d", l.end, l.size, tt.end, tt.size)
+			}
+		})
+	}
+}
+
+func TestStd140Pack(t *testing.T) {
+	type block struct {
+		Pos    mgl32.Vec3
+		Scale  float32
+		Lights [2]float32
+		On     bool
+		Model  mgl32.Mat3
+	}
+	value := block{
+		Pos:    mgl32.Vec3{1, 2, 3},
+		Scale:  4,
+		Lights: [2]float32{5, 6},
+		On:     true,
+		Model:  mgl32.Mat3{7, 8, 9, 10, 11, 12, 13, 14, 15},
+	}
+	l, err := std140LayoutOf(reflect.TypeOf(value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, l.size)
+	l.pack(unsafe.Pointer(&value), data)
+
+	floats := map[int]float32{
+		0: 1, 4: 2, 8: 3, 12: 4,
+		16: 5, 32: 6,
+		64: 7, 68: 8, 72: 9,
+		80: 10, 84: 11, 88: 12,
+		96: 13, 100: 14, 104: 15,
+	}
+	for offset, want := range floats {
+		if got := math.Float32frombits(binary.NativeEndian.Uint32(data[offset:])); got != want {
+			t.Errorf("float at %d is %v, want %v", offset, got, want)
+		}
+	}
+	if got := binary.NativeEndian.Uint32(data[48:]); got != 1 {
+		t.Errorf("bool at 48 is %d, want 1", got)
+	}
+}
@@ -0,0 +1,235 @@
+package helpers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+/*
+Every program's active uniforms are looked up once when it links
+so the setters don't have to ask GL for locations by name each call.
+Setting a uniform that doesn't exist or has a different type prints a
+warning the first time instead of being silently ignored like GL does.
+*/
+
+var samplerTypes = []uint32{
+	gl.SAMPLER_1D, gl.SAMPLER_2D, gl.SAMPLER_3D, gl.SAMPLER_CUBE,
+	gl.SAMPLER_1D_SHADOW, gl.SAMPLER_2D_SHADOW, gl.SAMPLER_CUBE_SHADOW,
+	gl.SAMPLER_1D_ARRAY, gl.SAMPLER_2D_ARRAY, gl.SAMPLER_2D_ARRAY_SHADOW,
+	gl.SAMPLER_2D_MULTISAMPLE, gl.SAMPLER_BUFFER, gl.SAMPLER_2D_RECT,
+	gl.INT_SAMPLER_2D, gl.INT_SAMPLER_3D, gl.INT_SAMPLER_CUBE, gl.INT_SAMPLER_2D_ARRAY,
+	gl.UNSIGNED_INT_SAMPLER_2D, gl.UNSIGNED_INT_SAMPLER_3D, gl.UNSIGNED_INT_SAMPLER_CUBE, gl.UNSIGNED_INT_SAMPLER_2D_ARRAY,
+}
+
+// the glsl name of every uniform type the setters know about
+var uniformTypeNames = allUniformTypeNames()
+
+func allUniformTypeNames() map[uint32]string {
+	names := map[uint32]string{
+		gl.BOOL:      "bool",
+		gl.BOOL_VEC2: "bvec2",
+		gl.BOOL_VEC3: "bvec3",
+		gl.BOOL_VEC4: "bvec4",
+
+		gl.SAMPLER_1D:                    "sampler1D",
+		gl.SAMPLER_2D:                    "sampler2D",
+		gl.SAMPLER_3D:                    "sampler3D",
+		gl.SAMPLER_CUBE:                  "samplerCube",
+		gl.SAMPLER_1D_SHADOW:             "sampler1DShadow",
+		gl.SAMPLER_2D_SHADOW:             "sampler2DShadow",
+		gl.SAMPLER_CUBE_SHADOW:           "samplerCubeShadow",
+		gl.SAMPLER_1D_ARRAY:              "sampler1DArray",
+		gl.SAMPLER_2D_ARRAY:              "sampler2DArray",
+		gl.SAMPLER_2D_ARRAY_SHADOW:       "sampler2DArrayShadow",
+		gl.SAMPLER_2D_MULTISAMPLE:        "sampler2DMS",
+		gl.SAMPLER_BUFFER:                "samplerBuffer",
+		gl.SAMPLER_2D_RECT:               "sampler2DRect",
+		gl.INT_SAMPLER_2D:                "isampler2D",
+		gl.INT_SAMPLER_3D:                "isampler3D",
+		gl.INT_SAMPLER_CUBE:              "isamplerCube",
+		gl.INT_SAMPLER_2D_ARRAY:          "isampler2DArray",
+		gl.UNSIGNED_INT_SAMPLER_2D:       "usampler2D",
+		gl.UNSIGNED_INT_SAMPLER_3D:       "usampler3D",
+		gl.UNSIGNED_INT_SAMPLER_CUBE:     "usamplerCube",
+		gl.UNSIGNED_INT_SAMPLER_2D_ARRAY: "usampler2DArray",
+	}
+	for xtype, shape := range glslShapes {
+		names[xtype] = shape.name
+	}
+	return names
+}
+
+func uniformTypeName(xtype uint32) string {
+	if name, ok := uniformTypeNames[xtype]; ok {
+		return name
+	}
+	return fmt.Sprintf("type 0x%x", xtype)
+}
+
+// an active uniform outside of any uniform block
+type uniformInfo struct {
+	location int32
+	glslType uint32
+	size     int32 //elements if it's an array, otherwise 1
+}
+
+var programUniforms = make(map[ProgramID]map[string]uniformInfo)
+
+// looks up the program's uniforms, arrays are stored without their [0]
+func reflectUniforms(program ProgramID) {
+	uniforms := make(map[string]uniformInfo)
+
+	count := backend.GetProgramiv(uint32(program), gl.ACTIVE_UNIFORMS)
+	for i := int32(0); i < count; i++ {
+		name, size, glslType := backend.GetActiveUniform(uint32(program), uint32(i))
+		name = strings.TrimSuffix(name, "[0]")
+
+		location := backend.GetUniformLocation(uint32(program), name)
+		if location < 0 {
+			continue //members of uniform blocks don't have locations
+		}
+		uniforms[name] = uniformInfo{location: location, glslType: glslType, size: size}
+	}
+	programUniforms[program] = uniforms
+}
+
+// the glsl type of each of the program's uniforms by name
+func (s *Shader) Uniforms() map[string]string {
+	types := make(map[string]string)
+	for name, u := range programUniforms[s.id] {
+		types[name] = uniformTypeName(u.glslType)
+		if u.size > 1 {
+			types[name] += fmt.Sprintf("[%d]", u.size)
+		}
+	}
+	return types
+}
+
+// finds where to put count values for the uniform, -1 if it doesn't exist
+// or its type isn't one of types. also returns how many values fit
+func (s *Shader) uniformLocation(name, setter string, count int, types ...uint32) (int32, int32) {
+	u, ok := programUniforms[s.id][name]
+	if s.broken {
+		//the error program only has model so the real uniforms are skipped without a warning
+		if !ok || !slices.Contains(types, u.glslType) {
+			return -1, 0
+		}
+		return u.location, int32(min(count, int(u.size)))
+	}
+	if !ok {
+		s.warnOnce(name, "doesn't exist or isn't used by the shader")
+		return -1, 0
+	}
+	if !slices.Contains(types, u.glslType) {
+		s.warnOnce(name, fmt.Sprintf("is %s but was set with %s", uniformTypeName(u.glslType), setter))
+		return -1, 0
+	}
+	if count > int(u.size) {
+		s.warnOnce(name, fmt.Sprintf("has %d elements but was given %d", u.size, count))
+		count = int(u.size)
+	}
+	return u.location, int32(count)
+}
+
+func (s *Shader) warnOnce(uniform, problem string) {
+	if s.warned == nil {
+		s.warned = make(map[string]bool)
+	}
+	if s.warned[uniform] {
+		return
+	}
+	s.warned[uniform] = true
+	fmt.Printf("Shader (%s): uniform %s %s\n", s.Name(), uniform, problem)
+}
+
+// bools and samplers can be set as ints too
+var intSetterTypes = append([]uint32{gl.INT, gl.BOOL}, samplerTypes...)
+
+func (s *Shader) SetFloat(name string, value float32) {
+	if loc, _ := s.uniformLocation(name, "SetFloat", 1, gl.FLOAT); loc >= 0 {
+		backend.Uniform1f(loc, value)
+	}
+}
+func (s *Shader) SetInt(name string, value int32) {
+	if loc, _ := s.uniformLocation(name, "SetInt", 1, intSetterTypes...); loc >= 0 {
+		backend.Uniform1i(loc, value)
+	}
+}
+func (s *Shader) SetBool(name string, value bool) {
+	if loc, _ := s.uniformLocation(name, "SetBool", 1, gl.BOOL); loc >= 0 {
+		var v int32
+		if value {
+			v = 1
+		}
+		backend.Uniform1i(loc, v)
+	}
+}
+
+// points a sampler at a texture unit, see BindTextureUnit
+func (s *Shader) SetSampler(name string, unit uint32) {
+	if loc, _ := s.uniformLocation(name, "SetSampler", 1, samplerTypes...); loc >= 0 {
+		backend.Uniform1i(loc, int32(unit))
+	}
+}
+func (s *Shader) SetVec2(name string, value mgl32.Vec2) {
+	if loc, _ := s.uniformLocation(name, "SetVec2", 1, gl.FLOAT_VEC2); loc >= 0 {
+		backend.Uniform2fv(loc, 1, &value[0])
+	}
+}
+func (s *Shader) SetVec3(name string, value mgl32.Vec3) {
+	if loc, _ := s.uniformLocation(name, "SetVec3", 1, gl.FLOAT_VEC3); loc >= 0 {
+		backend.Uniform3fv(loc, 1, &value[0])
+	}
+}
+func (s *Shader) SetVec4(name string, value mgl32.Vec4) {
+	if loc, _ := s.uniformLocation(name, "SetVec4", 1, gl.FLOAT_VEC4); loc >= 0 {
+		backend.Uniform4fv(loc, 1, &value[0])
+	}
+}
+func (s *Shader) SetMatrix3(name string, value mgl32.Mat3) {
+	if loc, _ := s.uniformLocation(name, "SetMatrix3", 1, gl.FLOAT_MAT3); loc >= 0 {
+		backend.UniformMatrix3fv(loc, 1, false, &value[0])
+	}
+}
+func (s *Shader) SetMatrix4(name string, value mgl32.Mat4) {
+	if loc, _ := s.uniformLocation(name, "SetMatrix4", 1, gl.FLOAT_MAT4); loc >= 0 {
+		backend.UniformMatrix4fv(loc, 1, false, &value[0])
+	}
+}
+
+// the array setters fill the array from its first element
+// values that don't fit are dropped with a warning
+func (s *Shader) SetFloatArray(name string, values []float32) {
+	if loc, n := s.uniformLocation(name, "SetFloatArray", len(values), gl.FLOAT); loc >= 0 && n > 0 {
+		backend.Uniform1fv(loc, n, &values[0])
+	}
+}
+func (s *Shader) SetIntArray(name string, values []int32) {
+	if loc, n := s.uniformLocation(name, "SetIntArray", len(values), intSetterTypes...); loc >= 0 && n > 0 {
+		backend.Uniform1iv(loc, n, &values[0])
+	}
+}
+func (s *Shader) SetVec2Array(name string, values []mgl32.Vec2) {
+	if loc, n := s.uniformLocation(name, "SetVec2Array", len(values), gl.FLOAT_VEC2); loc >= 0 && n > 0 {
+		backend.Uniform2fv(loc, n, &values[0][0])
+	}
+}
+func (s *Shader) SetVec3Array(name string, values []mgl32.Vec3) {
+	if loc, n := s.uniformLocation(name, "SetVec3Array", len(values), gl.FLOAT_VEC3); loc >= 0 && n > 0 {
+		backend.Uniform3fv(loc, n, &values[0][0])
+	}
+}
+func (s *Shader) SetVec4Array(name string, values []mgl32.Vec4) {
+	if loc, n := s.uniformLocation(name, "SetVec4Array", len(values), gl.FLOAT_VEC4); loc >= 0 && n > 0 {
+		backend.Uniform4fv(loc, n, &values[0][0])
+	}
+}
+func (s *Shader) SetMatrix4Array(name string, values []mgl32.Mat4) {
+	if loc, n := s.uniformLocation(name, "SetMatrix4Array", len(values), gl.FLOAT_MAT4); loc >= 0 && n > 0 {
+		backend.UniformMatrix4fv(loc, n, false, &values[0][0])
+	}
+}
@@ -0,0 +1,168 @@
+package helpers
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+/*
+Vertex layouts read from the fields of a Go struct so the
+segment lists can't get out of sync with the data e.g.
+
+	type Vertex struct {
+		Pos    mgl32.Vec3 `attr:"position"`
+		UV     mgl32.Vec2 `attr:"uv"`
+		Color  [4]uint8   `attr:"color,normalized"`
+		Bones  [4]uint8   `attr:"aBoneIds,integer"`
+		Normal [4]int8    `attr:"5,normalized"`
+	}
+
+the tag starts with a semantic or shader input name (see attributes.go)
+or a fixed attribute location, fields without a tag are skipped
+*/
+
+// a half float vertex component, made with HalfFloat(Float16(f))
+type HalfFloat uint16
+
+var halfFloatType = reflect.TypeOf(HalfFloat(0))
+
+// the layout of the vertex struct T, taken from its attr tags
+// panics if T isn't a struct or a tagged field can't be an attribute
+func VertexLayoutOf[T any]() VertexLayout {
+	var v T
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Errorf("vertex type %v isn't a struct", t))
+	}
+
+	l := VertexLayout{stride: int32(t.Size())}
+	used := make(map[string]string)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("attr")
+		if !ok {
+			continue
+		}
+
+		location, name, a, err := parseAttrField(field.Type, tag)
+		if err != nil {
+			panic(fmt.Errorf("%v.%s: %w", t, field.Name, err))
+		}
+		binding := name
+		if binding == "" {
+			binding = fmt.Sprintf("location %d", location)
+		}
+		if other, ok := used[binding]; ok {
+			panic(fmt.Errorf("%v.%s: %s is already used by %s", t, field.Name, binding, other))
+		}
+		used[binding] = field.Name
+
+		l.Attributes = append(l.Attributes, a)
+		l.offsets = append(l.offsets, field.Offset)
+		l.locations = append(l.locations, location)
+		l.names = append(l.names, name)
+	}
+
+	if len(l.Attributes) == 0 {
+		panic(fmt.Errorf("vertex type %v has no attr tagged fields", t))
+	}
+	return l
+}
+
+// reads a tag like "2,normalized" or "color,normalized" and the attribute for a field of type t
+// either the location or the name is set
+func parseAttrField(t reflect.Type, tag string) (uint32, string, VertexAttribute, error) {
+	parts := strings.Split(tag, ",")
+	var name string
+	binding := strings.TrimSpace(parts[0])
+	location, err := strconv.ParseUint(binding, 10, 32)
+	if err != nil {
+		if binding == "" || strings.ContainsAny(binding, " \t") {
+			return 0, "", VertexAttribute{}, fmt.Errorf("invalid attribute name %q", parts[0])
+		}
+		location, name = 0, binding
+	}
+
+	a := VertexAttribute{Components: 1}
+	if t.Kind() == reflect.Array {
+		a.Components = int32(t.Len())
+		t = t.Elem()
+	}
+	a.Type, err = attribTypeOf(t)
+	if err != nil {
+		return 0, "", VertexAttribute{}, err
+	}
+
+	for _, option := range parts[1:] {
+		switch strings.TrimSpace(option) {
+		case "normalized":
+			a.Normalized = true
+		case "integer":
+			a.Integer = true
+		default:
+			return 0, "", VertexAttribute{}, fmt.Errorf("unknown attr option %q", option)
+		}
+	}
+	return uint32(location), name, a, a.validate()
+}
+
+func attribTypeOf(t reflect.Type) (AttribType, error) {
+	if t == halfFloatType {
+		return HalfFloatAttrib, nil
+	}
+	switch t.Kind() {
+	case reflect.Float32:
+		return Float32Attrib, nil
+	case reflect.Int8:
+		return Int8Attrib, nil
+	case reflect.Uint8:
+		return Uint8Attrib, nil
+	case reflect.Int16:
+		return Int16Attrib, nil
+	case reflect.Uint16:
+		return Uint16Attrib, nil
+	case reflect.Int32:
+		return Int32Attrib, nil
+	case reflect.Uint32:
+		return Uint32Attrib, nil
+	default:
+		return 0, fmt.Errorf("%v can't be a vertex attribute", t)
+	}
+}
+
+// uploads the verticies into a new ARRAY_BUFFER and points the
+// vao's attributes at it using the layout from T's attr tags
+// every attribute needs a location, named ones are bound by Object
+func BuildVertexBuffer[T any](vao BufferID, verticies []T) BufferID {
+	layout := VertexLayoutOf[T]()
+	layout.requireLocations()
+
+	BindVertexArray(vao)
+	vbo := uploadVerticies(verticies)
+
+	for i, a := range layout.Attributes {
+		vertexAttribPointer(layout.locations[i], a, layout.Stride(), layout.Offset(i))
+	}
+	return vbo
+}
+
+// panics if an attribute is bound by name, those can only be used through an Object
+func (l VertexLayout) requireLocations() {
+	for i, name := range l.names {
+		if name != "" {
+			panic(fmt.Errorf("attribute %d (%s) is bound by name, it needs a location to build a buffer without a shader", i, name))
+		}
+	}
+}
+
+// copies the verticies into a new ARRAY_BUFFER
+func uploadVerticies[T any](verticies []T) BufferID {
+	vbo := GenBindBuffer(gl.ARRAY_BUFFER)
+	BufferData(gl.ARRAY_BUFFER, verticies, gl.STATIC_DRAW)
+	return vbo
+}
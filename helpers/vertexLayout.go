@@ -0,0 +1,329 @@
+package helpers
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// the component type of a vertex attribute
+type AttribType int
+
+const (
+	Float32Attrib AttribType = iota
+	HalfFloatAttrib
+	Int8Attrib
+	Uint8Attrib
+	Int16Attrib
+	Uint16Attrib
+	Int32Attrib
+	Uint32Attrib
+)
+
+func (t AttribType) String() string {
+	switch t {
+	case Float32Attrib:
+		return "float32"
+	case HalfFloatAttrib:
+		return "half float"
+	case Int8Attrib:
+		return "int8"
+	case Uint8Attrib:
+		return "uint8"
+	case Int16Attrib:
+		return "int16"
+	case Uint16Attrib:
+		return "uint16"
+	case Int32Attrib:
+		return "int32"
+	case Uint32Attrib:
+		return "uint32"
+	default:
+		return fmt.Sprintf("AttribType(%d)", int(t))
+	}
+}
+
+// size of one component in bytes
+func (t AttribType) Size() int32 {
+	switch t {
+	case Int8Attrib, Uint8Attrib:
+		return 1
+	case HalfFloatAttrib, Int16Attrib, Uint16Attrib:
+		return 2
+	case Float32Attrib, Int32Attrib, Uint32Attrib:
+		return 4
+	default:
+		panic(fmt.Errorf("unknown attribute type %v", t))
+	}
+}
+
+func (t AttribType) glType() uint32 {
+	switch t {
+	case Float32Attrib:
+		return gl.FLOAT
+	case HalfFloatAttrib:
+		return gl.HALF_FLOAT
+	case Int8Attrib:
+		return gl.BYTE
+	case Uint8Attrib:
+		return gl.UNSIGNED_BYTE
+	case Int16Attrib:
+		return gl.SHORT
+	case Uint16Attrib:
+		return gl.UNSIGNED_SHORT
+	case Int32Attrib:
+		return gl.INT
+	case Uint32Attrib:
+		return gl.UNSIGNED_INT
+	default:
+		panic(fmt.Errorf("unknown attribute type %v", t))
+	}
+}
+
+func (t AttribType) isFloat() bool {
+	return t == Float32Attrib || t == HalfFloatAttrib
+}
+
+// one attribute of an interleaved vertex
+type VertexAttribute struct {
+	Type       AttribType
+	Components int32 //1-4
+	Normalized bool  //integers become floats in 0-1 (unsigned) or -1-1 (signed)
+	Integer    bool  //integers stay ints in the shader e.g. bone indices (ivec4/uvec4)
+}
+
+func (a VertexAttribute) size() int32 {
+	return a.Type.Size() * a.Components
+}
+
+func (a VertexAttribute) validate() error {
+	switch {
+	case a.Components < 1 || a.Components > 4:
+		return fmt.Errorf("%v attribute has %d components, it needs 1-4", a.Type, a.Components)
+	case a.Type.isFloat() && (a.Normalized || a.Integer):
+		return fmt.Errorf("%v attribute can't be normalized or integer", a.Type)
+	case a.Normalized && a.Integer:
+		return fmt.Errorf("%v attribute can't be both normalized and integer", a.Type)
+	}
+	return nil
+}
+
+// points the attribute location at one attribute of the bound ARRAY_BUFFER
+func vertexAttribPointer(index uint32, a VertexAttribute, stride int32, offset uintptr) {
+	if a.Integer {
+		backend.VertexAttribIPointer(index, a.Components, a.Type.glType(), stride, offset)
+	} else {
+		backend.VertexAttribPointer(index, a.Components, a.Type.glType(), a.Normalized, stride, offset)
+	}
+	backend.EnableVertexAttribArray(index)
+}
+
+// the layout of a buffer of interleaved verticies that can mix attribute types
+// every attribute starts on a 4 byte boundary as GL prefers
+type VertexLayout struct {
+	Attributes []VertexAttribute
+	offsets    []uintptr
+	locations  []uint32 //set by VertexLayoutOf, otherwise counted by the BufferLoader
+	names      []string //the semantic or input name of each attribute, "" if it has a location
+	stride     int32
+}
+
+// panics if an attribute is invalid e.g. a normalized float
+func NewVertexLayout(attributes ...VertexAttribute) VertexLayout {
+	l := VertexLayout{
+		Attributes: attributes,
+		offsets:    make([]uintptr, len(attributes)),
+	}
+
+	for i, a := range attributes {
+		if err := a.validate(); err != nil {
+			panic(fmt.Errorf("attribute %d: %w", i, err))
+		}
+		l.offsets[i] = uintptr(l.stride)
+		l.stride += align4(a.size())
+	}
+	return l
+}
+
+func align4(n int32) int32 {
+	return (n + 3) &^ 3
+}
+
+// bytes between the start of each vertex
+func (l VertexLayout) Stride() int32 {
+	return l.stride
+}
+
+// bytes from the start of a vertex to attribute i
+func (l VertexLayout) Offset(i int) uintptr {
+	return l.offsets[i]
+}
+
+// packs verticies into the bytes a VertexLayout describes
+// each vertex is written one attribute at a time in layout order
+// and the method used has to match that attribute's type
+type VertexWriter struct {
+	layout    VertexLayout
+	data      []byte
+	attribute int //the next attribute to write
+}
+
+func NewVertexWriter(layout VertexLayout, vertexCount int) *VertexWriter {
+	if len(layout.Attributes) == 0 {
+		panic(fmt.Errorf("vertex layout has no attributes"))
+	}
+	w := VertexWriter{
+		layout: layout,
+		data:   make([]byte, 0, vertexCount*int(layout.stride)),
+	}
+	return &w
+}
+
+// the bytes for the next attribute, after checking it is the expected type
+func (w *VertexWriter) next(t AttribType, components int) []byte {
+	a := w.layout.Attributes[w.attribute]
+	if a.Type != t || int(a.Components) != components {
+		panic(fmt.Errorf("attribute %d is %d %v, got %d %v", w.attribute, a.Components, a.Type, components, t))
+	}
+
+	if w.attribute == 0 {
+		w.data = append(w.data, make([]byte, w.layout.stride)...)
+	}
+	start := len(w.data) - int(w.layout.stride) + int(w.layout.offsets[w.attribute])
+
+	w.attribute = (w.attribute + 1) % len(w.layout.Attributes)
+	return w.data[start : start+int(a.size())]
+}
+
+func (w *VertexWriter) Float32(v ...float32) {
+	dst := w.next(Float32Attrib, len(v))
+	for i, f := range v {
+		binary.NativeEndian.PutUint32(dst[i*4:], math.Float32bits(f))
+	}
+}
+
+// converts the values to half floats
+func (w *VertexWriter) HalfFloat(v ...float32) {
+	dst := w.next(HalfFloatAttrib, len(v))
+	for i, f := range v {
+		binary.NativeEndian.PutUint16(dst[i*2:], Float16(f))
+	}
+}
+
+func (w *VertexWriter) Int8(v ...int8) {
+	dst := w.next(Int8Attrib, len(v))
+	for i, n := range v {
+		dst[i] = byte(n)
+	}
+}
+
+func (w *VertexWriter) Uint8(v ...uint8) {
+	dst := w.next(Uint8Attrib, len(v))
+	copy(dst, v)
+}
+
+func (w *VertexWriter) Int16(v ...int16) {
+	dst := w.next(Int16Attrib, len(v))
+	for i, n := range v {
+		binary.NativeEndian.PutUint16(dst[i*2:], uint16(n))
+	}
+}
+
+func (w *VertexWriter) Uint16(v ...uint16) {
+	dst := w.next(Uint16Attrib, len(v))
+	for i, n := range v {
+		binary.NativeEndian.PutUint16(dst[i*2:], n)
+	}
+}
+
+func (w *VertexWriter) Int32(v ...int32) {
+	dst := w.next(Int32Attrib, len(v))
+	for i, n := range v {
+		binary.NativeEndian.PutUint32(dst[i*4:], uint32(n))
+	}
+}
+
+func (w *VertexWriter) Uint32(v ...uint32) {
+	dst := w.next(Uint32Attrib, len(v))
+	for i, n := range v {
+		binary.NativeEndian.PutUint32(dst[i*4:], n)
+	}
+}
+
+// number of complete verticies written so far
+func (w *VertexWriter) VertexCount() int {
+	n := len(w.data) / int(w.layout.stride)
+	if w.attribute != 0 {
+		n--
+	}
+	return n
+}
+
+// the packed verticies, panics if the last vertex isn't finished
+func (w *VertexWriter) Bytes() []byte {
+	if w.attribute != 0 {
+		panic(fmt.Errorf("vertex %d is missing attributes %d-%d", w.VertexCount(), w.attribute, len(w.layout.Attributes)-1))
+	}
+	return w.data
+}
+
+// converts f to an IEEE half float, rounding to the nearest even value
+// values too big for a half become infinity
+func Float16(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int32(bits>>23&0xff) - 127 + 15
+	mantissa := bits & 0x7fffff
+
+	switch {
+	case bits>>23&0xff == 0xff: //inf or NaN
+		if mantissa != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	case exp >= 0x1f:
+		return sign | 0x7c00
+	case exp <= 0: //too small for a normal half, becomes subnormal or zero
+		if exp < -10 {
+			return sign
+		}
+		mantissa |= 0x800000
+		shift := uint32(14 - exp)
+		half := mantissa >> shift
+		rest, halfway := mantissa&(1<<shift-1), uint32(1)<<(shift-1)
+		if rest > halfway || (rest == halfway && half&1 == 1) {
+			half++
+		}
+		return sign | uint16(half)
+	default:
+		half := uint32(exp)<<10 | mantissa>>13
+		rest := mantissa & 0x1fff
+		if rest > 0x1000 || (rest == 0x1000 && half&1 == 1) {
+			half++ //a carry into the exponent is still correct
+		}
+		return sign | uint16(half)
+	}
+}
+
+// converts -1 to 1 into the int8 a normalized attribute reads back as f
+func NormalizedInt8(f float32) int8 {
+	return int8(math.Round(float64(mgl32.Clamp(f, -1, 1) * math.MaxInt8)))
+}
+
+// converts 0 to 1 into the uint8 a normalized attribute reads back as f
+func NormalizedUint8(f float32) uint8 {
+	return uint8(math.Round(float64(mgl32.Clamp(f, 0, 1) * math.MaxUint8)))
+}
+
+// converts -1 to 1 into the int16 a normalized attribute reads back as f
+func NormalizedInt16(f float32) int16 {
+	return int16(math.Round(float64(mgl32.Clamp(f, -1, 1) * math.MaxInt16)))
+}
+
+// converts 0 to 1 into the uint16 a normalized attribute reads back as f
+func NormalizedUint16(f float32) uint16 {
+	return uint16(math.Round(float64(mgl32.Clamp(f, 0, 1) * math.MaxUint16)))
+}
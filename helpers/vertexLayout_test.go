@@ -0,0 +1,145 @@
+package helpers
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestFloat16(t *testing.T) {
+	tests := []struct {
+		name string
+		f    float32
+		want uint16
+	}{
+		{"zero", 0, 0x0000},
+		{"negative zero", float32(math.Copysign(0, -1)), 0x8000},
+		{"one", 1, 0x3c00},
+		{"minus one", -1, 0xbc00},
+		{"half", 0.5, 0x3800},
+		{"largest half", 65504, 0x7bff},
+		{"rounds up to infinity", 65520, 0x7c00},
+		{"overflow", 1e6, 0x7c00},
+		{"negative overflow", -1e6, 0xfc00},
+		{"infinity", float32(math.Inf(1)), 0x7c00},
+		{"negative infinity", float32(math.Inf(-1)), 0xfc00},
+		{"smallest normal", 1.0 / (1 << 14), 0x0400},
+		{"largest subnormal", 1.0/(1<<14) - 1.0/(1<<24), 0x03ff},
+		{"smallest subnormal", 1.0 / (1 << 24), 0x0001},
+		{"negative subnormal", -1.0 / (1 << 24), 0x8001},
+		{"halfway to the smallest subnormal rounds to even", 1.0 / (1 << 25), 0x0000},
+		{"past halfway to the smallest subnormal", 1.5 / (1 << 25), 0x0001},
+		{"underflow", 1e-10, 0x0000},
+		{"halfway rounds down to even", 1 + 1.0/(1<<11), 0x3c00},
+		{"halfway rounds up to even", 1 + 3.0/(1<<11), 0x3c02},
+		{"rounds into the next exponent", 2 - 1.0/(1<<12), 0x4000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Float16(tt.f); got != tt.want {
+				t.Errorf("Float16(%v) got %#04x, want %#04x", tt.f, got, tt.want)
+			}
+		})
+	}
+
+	for _, sign := range []float64{1, -1} {
+		nan := Float16(float32(math.Copysign(math.NaN(), sign)))
+		if nan&0x7c00 != 0x7c00 || nan&0x03ff == 0 {
+			t.Errorf("NaN became %#04x which isn't a NaN", nan)
+		}
+	}
+}
+
+func TestVertexWriter(t *testing.T) {
+	layout := NewVertexLayout(
+		VertexAttribute{Type: Float32Attrib, Components: 3},
+		VertexAttribute{Type: HalfFloatAttrib, Components: 2},
+		VertexAttribute{Type: Int8Attrib, Components: 3, Normalized: true},
+		VertexAttribute{Type: Uint16Attrib, Components: 1},
+		VertexAttribute{Type: Int32Attrib, Components: 1, Integer: true},
+	)
+	//every attribute starts on a 4 byte boundary
+	wantOffsets := []uintptr{0, 12, 16, 20, 24}
+	for i, want := range wantOffsets {
+		if got := layout.Offset(i); got != want {
+			t.Errorf("attribute %d is at %d, want %d", i, got, want)
+		}
+	}
+	if layout.Stride() != 28 {
+		t.Errorf("got stride %d, want 28", layout.Stride())
+	}
+
+	w := NewVertexWriter(layout, 2)
+	for v := 0; v < 2; v++ {
+		n := float32(v + 1)
+		w.Float32(n, 2*n, 3*n)
+		w.HalfFloat(n, -n)
+		w.Int8(NormalizedInt8(1), NormalizedInt8(-1), int8(v))
+		w.Uint16(uint16(1000 * n))
+		w.Int32(int32(-v))
+	}
+	if w.VertexCount() != 2 {
+		t.Errorf("got %d verticies, want 2", w.VertexCount())
+	}
+
+	data := w.Bytes()
+	if len(data) != 2*28 {
+		t.Fatalf("got %d bytes, want %d", len(data), 2*28)
+	}
+	for v := 0; v < 2; v++ {
+		n := float32(v + 1)
+		at := func(attribute int) []byte {
+			return data[v*int(layout.Stride())+int(layout.Offset(attribute)):]
+		}
+		for c, want := range []float32{n, 2 * n, 3 * n} {
+			if got := math.Float32frombits(binary.NativeEndian.Uint32(at(0)[c*4:])); got != want {
+				t.Errorf("vertex %d position %d is %v, want %v", v, c, got, want)
+			}
+		}
+		for c, want := range []uint16{Float16(n), Float16(-n)} {
+			if got := binary.NativeEndian.Uint16(at(1)[c*2:]); got != want {
+				t.Errorf("vertex %d half %d is %#04x, want %#04x", v, c, got, want)
+			}
+		}
+		for c, want := range []int8{127, -127, int8(v)} {
+			if got := int8(at(2)[c]); got != want {
+				t.Errorf("vertex %d byte %d is %d, want %d", v, c, got, want)
+			}
+		}
+		if got := binary.NativeEndian.Uint16(at(3)); got != uint16(1000*n) {
+			t.Errorf("vertex %d uint16 is %d, want %d", v, got, uint16(1000*n))
+		}
+		if got := int32(binary.NativeEndian.Uint32(at(4))); got != int32(-v) {
+			t.Errorf("vertex %d int32 is %d, want %d", v, got, -v)
+		}
+	}
+}
+
+func TestVertexWriterPanics(t *testing.T) {
+	layout := NewVertexLayout(
+		VertexAttribute{Type: Float32Attrib, Components: 3},
+		VertexAttribute{Type: Uint8Attrib, Components: 4, Normalized: true},
+	)
+	panics := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s didn't panic", name)
+			}
+		}()
+		f()
+	}
+
+	panics("the wrong type", func() { NewVertexWriter(layout, 1).HalfFloat(1, 2, 3) })
+	panics("the wrong number of components", func() { NewVertexWriter(layout, 1).Float32(1, 2) })
+	panics("an unfinished vertex", func() {
+		w := NewVertexWriter(layout, 1)
+		w.Float32(1, 2, 3)
+		if w.VertexCount() != 0 {
+			t.Errorf("an unfinished vertex was counted")
+		}
+		w.Bytes()
+	})
+	panics("a normalized float", func() { NewVertexLayout(VertexAttribute{Type: Float32Attrib, Components: 1, Normalized: true}) })
+}
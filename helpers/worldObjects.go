@@ -5,74 +5,142 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 )
 
+// the GPU side of a mesh, made by MeshData.Upload
 type Object struct {
-	verticies    []float32 //in XYZ UV
-	vertexStride int       // 5 if using XYZ UV
-	normals      []float32
-	bufferLoader *BufferLoader
-	vao          BufferID
-	nao          BufferID
+	vertexCount int
+	indexCount  int //0 if drawing unindexed triangles
+	bounds      AABB
+	sphere      BoundingSphere
+	layout      VertexLayout
+	vbo         BufferID
+	ebo         BufferID
+	vaos        map[vaoKey]objectVAO //one per program it has been drawn with, see attributes.go
+
+	instanceBuffer BufferID //filled by DrawInstanced
 }
 
-func (o *Object) fillBuffers() {
-	o.bufferLoader = NewBufferLoader()
-	o.vao = GenBindVertexArray()
-	o.nao = GenBindBuffer(gl.ARRAY_BUFFER)
+func (o *Object) fillBuffers(m *MeshData) {
+	o.vertexCount = m.VertexCount()
+	o.indexCount = len(m.Indices)
+	o.bounds = m.Bounds()
+	o.sphere = m.BoundingSphere()
+
+	o.layout = VertexLayoutOf[meshVertex]()
+	o.vbo = uploadVerticies(m.verticies())
+	o.vaos = make(map[vaoKey]objectVAO)
+	//made here rather than on the first instanced draw so copies of the Object share it
+	o.instanceBuffer = GenBindBuffer(gl.ARRAY_BUFFER)
+
+	if m.Indexed() {
+		//uploaded through ARRAY_BUFFER as there might not be a VAO bound
+		//each VAO binds it as its element buffer
+		o.ebo = GenBindBuffer(gl.ARRAY_BUFFER)
+		BufferData(gl.ARRAY_BUFFER, m.Indices, gl.STATIC_DRAW)
+	}
+}
 
-	GenBindBuffer(gl.ARRAY_BUFFER) //VBO
+func (o Object) drawCall() {
+	if o.indexCount > 0 {
+		backend.DrawElements(gl.TRIANGLES, int32(o.indexCount), gl.UNSIGNED_INT, 0)
+	} else {
+		backend.DrawArrays(gl.TRIANGLES, 0, int32(o.vertexCount))
+	}
+}
 
-	BindVertexArray(o.vao)
-	o.bufferLoader.BuildFloatBuffer(o.vao, NewBufferLayout([]int32{3, 2}, o.verticies))
-	gl.BindBuffer(gl.ARRAY_BUFFER, uint32(o.nao))
-	o.bufferLoader.BuildFloatBuffer(o.nao, NewBufferLayout([]int32{3}, o.normals))
+// draws count indices starting at first, the object has to be indexed
+func (o Object) drawRange(first, count int) {
+	backend.DrawElements(gl.TRIANGLES, int32(count), gl.UNSIGNED_INT, uintptr(first*4))
 }
 
-func (o *Object) calcNormals(triangleCount int) {
-	vertexCount := triangleCount * 3 //3 bc we are working in 3d space so XYZ
-
-	o.normals = make([]float32, vertexCount*3)
-	for tri := 0; tri < triangleCount; tri++ {
-		index := tri * o.vertexStride * 3
-		p1 := mgl32.Vec3{o.verticies[index], o.verticies[index+1], o.verticies[index+2]}
-		index += o.vertexStride
-		p2 := mgl32.Vec3{o.verticies[index], o.verticies[index+1], o.verticies[index+2]}
-		index += o.vertexStride
-		p3 := mgl32.Vec3{o.verticies[index], o.verticies[index+1], o.verticies[index+2]}
-
-		normal := TriangleNormal(p1, p2, p3)
-		o.normals[tri*9+0] = normal.X()
-		o.normals[tri*9+1] = normal.Y()
-		o.normals[tri*9+2] = normal.Z()
-
-		o.normals[tri*9+3] = normal.X()
-		o.normals[tri*9+4] = normal.Y()
-		o.normals[tri*9+5] = normal.Z()
-
-		o.normals[tri*9+6] = normal.X()
-		o.normals[tri*9+7] = normal.Y()
-		o.normals[tri*9+8] = normal.Z()
+// frees every GL object this Object owns
+// it can't be drawn afterwards
+func (o *Object) Delete() {
+	for _, vao := range o.vaos {
+		DeleteVertexArray(vao.id)
+	}
+	for _, b := range []BufferID{o.vbo, o.ebo, o.instanceBuffer} {
+		DeleteBuffer(b)
+	}
+	for key := range bindFailures {
+		if key.vbo == o.vbo {
+			delete(bindFailures, key) //the id can be reused
+		}
 	}
+	*o = Object{}
+}
+
+// replaces the mesh with m, for meshes animated on the CPU
+// the old storage is orphaned so this doesn't wait for draws still using it
+// copies of the Object made before the update keep the old counts and bounds
+func (o *Object) UpdateMesh(m *MeshData) {
+	m.prepareUpload()
+
+	o.vertexCount = m.VertexCount()
+	o.indexCount = len(m.Indices)
+	o.bounds = m.Bounds()
+	o.sphere = m.BoundingSphere()
+
+	backend.BindBuffer(gl.ARRAY_BUFFER, uint32(o.vbo))
+	BufferData(gl.ARRAY_BUFFER, m.verticies(), gl.DYNAMIC_DRAW)
+
+	if m.Indexed() {
+		if o.ebo == 0 {
+			o.ebo = GenBindBuffer(gl.ARRAY_BUFFER)
+			//the VAOs were made without an element buffer so they are rebuilt on the next draw
+			for key, vao := range o.vaos {
+				DeleteVertexArray(vao.id)
+				delete(o.vaos, key)
+			}
+		}
+		backend.BindBuffer(gl.ARRAY_BUFFER, uint32(o.ebo))
+		BufferData(gl.ARRAY_BUFFER, m.Indices, gl.DYNAMIC_DRAW)
+	}
+}
+
+// the bounding box of the mesh in model space
+func (o Object) Bounds() AABB {
+	return o.bounds
+}
+
+func (o Object) BoundingSphere() BoundingSphere {
+	return o.sphere
 }
 
 func (o Object) Draw(shader *Shader, drawMatrix mgl32.Mat4) {
-	BindVertexArray(o.vao)
+	if activeCuller != nil && !activeCuller.Visible(o, drawMatrix) {
+		return
+	}
+	if !o.bindForDraw(shader, false) {
+		return
+	}
 
 	shader.SetMatrix4("model", drawMatrix)
-	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(o.verticies)/o.vertexStride))
+	o.drawCall()
+	countDrawn(1)
 }
 
 func (o Object) DrawMultiple(shader *Shader, num int, drawMatrix func(int) mgl32.Mat4) {
-	BindVertexArray(o.vao)
+	if !o.bindForDraw(shader, false) {
+		return
+	}
 
 	for i := 0; i < num; i++ {
-		shader.SetMatrix4("model", drawMatrix(i))
-		gl.DrawArrays(gl.TRIANGLES, 0, int32(len(o.verticies)/o.vertexStride))
+		matrix := drawMatrix(i)
+		if activeCuller != nil && !activeCuller.Visible(o, matrix) {
+			continue
+		}
+		shader.SetMatrix4("model", matrix)
+		o.drawCall()
+		countDrawn(1)
 	}
 }
 
 func Cube(size float32) Object {
-	o := Object{}
-	o.verticies = []float32{
+	return CubeMesh(size).Upload()
+}
+
+func CubeMesh(size float32) *MeshData {
+	m := meshFromVerticies([]float32{
 		-size / 2, -size / 2, -size / 2, 0.0, 0.0,
 		size / 2, size / 2, -size / 2, 1.0, 1.0,
 		size / 2, -size / 2, -size / 2, 1.0, 0.0,
@@ -114,18 +182,20 @@ func Cube(size float32) Object {
 		size / 2, size / 2, size / 2, 1.0, 0.0,
 		-size / 2, size / 2, -size / 2, 0.0, 1.0,
 		-size / 2, size / 2, size / 2, 0.0, 0.0,
-	}
-	o.vertexStride = 5
+	})
 
-	o.calcNormals(12)
-	o.fillBuffers()
+	m.CalcNormals(NormalOptions{Mode: FlatNormals})
+	m.Weld()
 
-	return o
+	return m
 }
 
 func Pentahedron(size float32) Object {
-	o := Object{}
-	o.verticies = []float32{
+	return PentahedronMesh(size).Upload()
+}
+
+func PentahedronMesh(size float32) *MeshData {
+	m := meshFromVerticies([]float32{
 		size / 2, -size / 2, size / 2, 0.0, 1.0,
 		-size / 2, -size / 2, -size / 2, 1.0, 0.0,
 		size / 2, -size / 2, -size / 2, 0.0, 0.0,
@@ -148,11 +218,10 @@ func Pentahedron(size float32) Object {
 		0.0, size / 2, 0.0, 0.5, 1.0,
 		-size / 2, -size / 2, -size / 2, 1.0, 0.0,
 		-size / 2, -size / 2, size / 2, 0.0, 0.0,
-	}
-	o.vertexStride = 5
+	})
 
-	o.calcNormals(6)
-	o.fillBuffers()
+	m.CalcNormals(NormalOptions{Mode: FlatNormals})
+	m.Weld()
 
-	return o
+	return m
 }
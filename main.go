@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
@@ -14,8 +15,24 @@ const (
 	cameraFov  float32 = 45
 	cameraNear float32 = 0.1
 	cameraFar  float32 = 100.0
+
+	// report GL objects that were never deleted when the app closes
+	trackResources = true
 )
 
+// the Camera and Lighting uniform blocks shared by every shader
+type cameraBlock struct {
+	Proj    mgl32.Mat4
+	View    mgl32.Mat4
+	ViewPos mgl32.Vec3
+}
+
+type lightingBlock struct {
+	LightPos     mgl32.Vec3
+	LightColor   mgl32.Vec3
+	AmbientLight mgl32.Vec3
+}
+
 var (
 	windowWidth  int32 = 1280
 	windowHeight int32 = 720
@@ -25,16 +42,60 @@ func main() {
 	window, cleanup := helpers.SetupFPSWindow("Learning Project", windowWidth, windowHeight)
 	defer cleanup()
 
+	helpers.EnableResourceTracking(trackResources)
+
 	fmt.Println("OpenGL Version", helpers.GetVersion())
 
 	window.WarpMouseInWindow(windowWidth/2, windowHeight/2)
 
-	shaderProgram := helpers.NewShader("assets/shaders/test.vert", "assets/shaders/quadTexture.frag")
-	texture := helpers.LoadTexture("assets/textures/metal/metalbox_full.png")
+	//a typo while live editing a shader draws its objects in magenta instead of closing
+	helpers.ShowShaderErrors(true)
+
+	uber := helpers.NewShaderVariants(
+		[]string{"assets/shaders/uber.vert", "assets/shaders/uber.frag"},
+		"NORMAL_MAP", "AO_MAP", "INSTANCED", "FOG",
+	)
+	shaderProgram := uber.Get("NORMAL_MAP", "FOG")
+	instancedShader := uber.Get("NORMAL_MAP", "FOG", "INSTANCED")
+	shaders := []*helpers.Shader{shaderProgram, instancedShader}
+	normalShader := helpers.NewShaderFromFiles("assets/shaders/normals.vert", "assets/shaders/normals.geom", "assets/shaders/normals.frag")
+	texture := helpers.LoadTexture("assets/textures/metal/metalbox_diffuse.png")
+	normalMap := helpers.LoadTexture("assets/textures/metal/metalbox_normal.png")
+
+	cameraUniforms := helpers.NewUniformBuffer[cameraBlock]("Camera")
+	lightingUniforms := helpers.NewUniformBuffer[lightingBlock]("Lighting")
+	lightingUniforms.Set(lightingBlock{
+		LightPos:     mgl32.Vec3{3.3, 1, 0},
+		LightColor:   mgl32.Vec3{1, 1, 1},
+		AmbientLight: mgl32.Vec3{0.3, 0.3, 0.3},
+	})
 
 	cube := helpers.Cube(1)
-	cubeBig := helpers.Cube(4)
-	pent := helpers.Pentahedron(2)
+
+	//the static scenery is merged into one buffer and drawn with a call per texture
+	scenery := helpers.NewStaticBatch[helpers.TextureID]()
+	scenery.Add(helpers.CubeMesh(4), mgl32.Translate3D(0, 5, 0), texture)
+	scenery.Add(helpers.PentahedronMesh(2), mgl32.Translate3D(0, 1, 0), texture)
+	for i := 0; i < 8; i++ {
+		angle := float32(i) * 45
+		scenery.Add(helpers.PentahedronMesh(0.5), mgl32.Translate3D(6*helpers.Cos32Deg(angle), -1, 6*helpers.Sin32Deg(angle)), texture)
+	}
+
+	//runs before cleanup so the GL context still exists
+	defer func() {
+		uber.Delete()
+		normalShader.Delete()
+		texture.Delete()
+		normalMap.Delete()
+		cameraUniforms.Delete()
+		lightingUniforms.Delete()
+		cube.Delete()
+		scenery.Delete()
+
+		if trackResources {
+			helpers.ReportLiveResources(os.Stdout)
+		}
+	}()
 
 	cubePositions := []mgl32.Vec3{
 		{0.0, 0.0, 0.0},
@@ -59,6 +120,9 @@ func main() {
 	worldUp := mgl32.Vec3{0.0, 1.0, 0.0}
 	camera := helpers.NewCamera(camPos, worldUp, 90, 0, 0.0025, 0.1)
 
+	culler := helpers.NewCuller()
+	helpers.UseCuller(culler)
+
 	elapsedTime := float32(0)
 	for {
 		frameStart := time.Now()
@@ -79,6 +143,7 @@ func main() {
 		}
 		if keyboardState[sdl.SCANCODE_I] != 0 {
 			fmt.Printf("Yaw: %v, Pitch %v\n", camera.Yaw, camera.Pitch)
+			fmt.Printf("Drawn: %v, Culled %v\n", culler.Drawn, culler.Culled)
 		}
 		if focusCooldown == 0 {
 			if keyboardState[sdl.SCANCODE_F] != 0 {
@@ -116,29 +181,45 @@ func main() {
 
 			camera.UpdateCamera(dirs, elapsedTime, mouseDx, mouseDy)
 		}
-		shaderProgram.Use()
-
 		projMat := mgl32.Perspective(mgl32.DegToRad(cameraFov), float32(windowWidth)/float32(windowHeight), cameraNear, cameraFar)
 		viewMat := camera.GetViewMatrix()
-		shaderProgram.SetMatrix4("proj", projMat)
-		shaderProgram.SetMatrix4("view", viewMat)
-
-		shaderProgram.SetVec3("viewPos", camera.Pos)
-		shaderProgram.SetVec3("lightPos", mgl32.Vec3{3.3, 1, 0})
-		shaderProgram.SetVec3("lightColor", mgl32.Vec3{1, 1, 1})
-		shaderProgram.SetVec3("ambientLight", mgl32.Vec3{0.3, 0.3, 0.3})
+		culler.Update(projMat, viewMat)
+		cameraUniforms.Set(cameraBlock{
+			Proj:    projMat,
+			View:    viewMat,
+			ViewPos: camera.Pos,
+		})
 
-		helpers.BindTexture(texture)
+		for _, s := range shaders {
+			s.Use()
+			s.SetSampler("texture1", 0)
+			s.SetSampler("normalMap", 1)
+			s.SetVec3("fogColor", mgl32.Vec3{0, 0, 0})
+			s.SetFloat("fogDensity", 0.04)
+		}
+		helpers.BindTextureUnit(0, texture)
+		helpers.BindTextureUnit(1, normalMap)
 
-		cube.DrawMultiple(shaderProgram, len(cubePositions), func(i int) mgl32.Mat4 {
+		instancedShader.Use()
+		cube.DrawMultipleInstanced(instancedShader, len(cubePositions), func(i int) mgl32.Mat4 {
 			pos := cubePositions[i]
 			return mgl32.Ident4().Mul4(mgl32.Translate3D(pos.X(), pos.Y(), pos.Z()))
 		})
-		cubeBig.Draw(shaderProgram, mgl32.Ident4().Mul4(mgl32.Translate3D(0, 5, 0)))
-		pent.Draw(shaderProgram, mgl32.Ident4().Mul4(mgl32.Translate3D(0, 1, 0)))
+
+		shaderProgram.Use()
+		scenery.Draw(shaderProgram, func(diffuse helpers.TextureID) {
+			helpers.BindTextureUnit(0, diffuse)
+		})
+
+		//hold N to see the scenery's normals
+		if keyboardState[sdl.SCANCODE_N] != 0 {
+			normalShader.Use()
+			normalShader.SetFloat("normalLength", 0.2)
+			scenery.Draw(normalShader, nil)
+		}
 
 		window.GLSwap()
-		shaderProgram.CheckShadersForChanges()
+		helpers.ReloadChangedShaders()
 
 		elapsedTime = float32(time.Since(frameStart).Seconds() * 1000)
 